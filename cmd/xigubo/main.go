@@ -24,9 +24,10 @@ import (
 )
 
 func main() {
-	if err := cmd.NewCmdRoot(
-		internal.NewCommandOpts(),
-	).Execute(); err != nil {
-		os.Exit(1)
+	opts := internal.NewCommandOpts()
+
+	if err := cmd.NewCmdRoot(opts).Execute(); err != nil {
+		cmd.PrintError(opts.Stderr, err)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,223 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/build"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+)
+
+const (
+	Version = "v2"
+
+	HeaderRequestID = "X-Request-Id"
+
+	mediaTypeJSON = "application/json"
+)
+
+type Client struct {
+	baseURL    *url.URL
+	account    string
+	token      string
+	userAgent  string
+	httpClient *http.Client
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces the HTTP client used to talk to the API.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+func NewClient(cfg *config.Config, opts ...Option) (*Client, error) {
+	baseURL, err := url.Parse(cfg.Endpoint())
+	if err != nil {
+		return nil, fmt.Errorf("invalid base url: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if cfg.CAFile != "" {
+		pool, err := loadCertPool(cfg.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs:    pool,
+			MinVersion: tls.VersionTLS12,
+		}
+	}
+
+	c := &Client{
+		baseURL:    baseURL,
+		account:    cfg.Account,
+		token:      cfg.AccessToken,
+		userAgent:  "xibugo-cli/" + build.Version,
+		httpClient: &http.Client{Transport: transport},
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c, nil
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading ca file: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificates found in %s", path)
+	}
+
+	return pool, nil
+}
+
+// accountPath builds the path of a resource owned by the configured account.
+func (c *Client) accountPath(format string, args ...interface{}) string {
+	return fmt.Sprintf("/%s/accounts/%s", Version, url.PathEscape(c.account)) + fmt.Sprintf(format, args...)
+}
+
+// NewRequest creates an API request. The path is resolved against the base
+// URL and body, when not nil, is encoded as JSON.
+func (c *Client) NewRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	u := strings.TrimSuffix(c.baseURL.String(), "/") + "/" + strings.TrimPrefix(path, "/")
+
+	var r io.Reader
+	if body != nil {
+		buf := new(bytes.Buffer)
+		if err := json.NewEncoder(buf).Encode(body); err != nil {
+			return nil, err
+		}
+
+		r = buf
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", mediaTypeJSON)
+	req.Header.Set("User-Agent", c.userAgent)
+
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", mediaTypeJSON)
+	}
+
+	return req, nil
+}
+
+// Do sends the request and decodes the response body into v. Responses
+// outside the 2xx range are returned as *Error, failures to reach the API as
+// *NetworkError.
+func (c *Client) Do(req *http.Request, v interface{}) (*http.Response, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+
+		return nil, &NetworkError{Err: err}
+	}
+
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return resp, err
+	}
+
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return resp, nil
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil && !errors.Is(err, io.EOF) {
+		return resp, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return resp, nil
+}
+
+// envelope is the shape of every successful response body.
+type envelope struct {
+	Data       interface{} `json:"data"`
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// call sends a request and decodes the "data" member of the response into v.
+func (c *Client) call(ctx context.Context, method, path string, body, v interface{}) error {
+	req, err := c.NewRequest(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Do(req, &envelope{Data: v})
+
+	return err
+}
+
+func (c *Client) get(ctx context.Context, path string, v interface{}) error {
+	return c.call(ctx, http.MethodGet, path, nil, v)
+}
+
+// list fetches a page of a collection into v.
+func (c *Client) list(ctx context.Context, path string, query url.Values, v interface{}) (*Pagination, error) {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	env := envelope{Data: v, Pagination: &Pagination{}}
+	if _, err := c.Do(req, &env); err != nil {
+		return nil, err
+	}
+
+	return env.Pagination, nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Classes of failure an API call can end with. They are matched with
+// errors.Is against the errors returned by the client.
+var (
+	ErrUnauthorized = errorClass("unauthorized")
+	ErrNotFound     = errorClass("not found")
+	ErrConflict     = errorClass("conflict")
+	ErrValidation   = errorClass("validation failed")
+	ErrRateLimited  = errorClass("rate limited")
+	ErrServer       = errorClass("server error")
+	ErrNetwork      = errorClass("network error")
+)
+
+type errorClass string
+
+func (e errorClass) Error() string {
+	return string(e)
+}
+
+// FieldError describes why a single field of a request was rejected.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error is an error response returned by the API.
+type Error struct {
+	StatusCode int          `json:"status"`
+	Code       string       `json:"code,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	Details    []FieldError `json:"details,omitempty"`
+	RequestID  string       `json:"request_id,omitempty"`
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = strings.ToLower(http.StatusText(e.StatusCode))
+	}
+
+	if e.Code != "" {
+		return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Code, msg)
+	}
+
+	return fmt.Sprintf("%d: %s", e.StatusCode, msg)
+}
+
+// Is reports whether the error belongs to the given class.
+func (e *Error) Is(target error) bool {
+	class, ok := target.(errorClass)
+	if !ok {
+		return false
+	}
+
+	return e.class() == class
+}
+
+func (e *Error) class() errorClass {
+	switch {
+	case e.StatusCode == http.StatusUnauthorized, e.StatusCode == http.StatusForbidden:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusUnprocessableEntity, e.StatusCode == http.StatusBadRequest:
+		return ErrValidation
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	}
+
+	return ""
+}
+
+// NetworkError is returned when the API could not be reached at all.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string {
+	return fmt.Sprintf("network error: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+func (e *NetworkError) Is(target error) bool {
+	return target == ErrNetwork
+}
+
+const maxErrorBodySize = 1 << 20
+
+func checkResponse(resp *http.Response) error {
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	apiErr := &Error{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get(HeaderRequestID),
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+	if err != nil || len(body) == 0 {
+		return apiErr
+	}
+
+	envelope := struct {
+		Error *Error `json:"error"`
+	}{Error: apiErr}
+
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+
+	apiErr.StatusCode = resp.StatusCode
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = resp.Header.Get(HeaderRequestID)
+	}
+
+	return apiErr
+}
@@ -0,0 +1,108 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type EventType struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+	Version     int             `json:"version,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// SchemaVersion is a schema an event type has had. Versions are numbered
+// from 1 and a new one is recorded each time the schema changes.
+type SchemaVersion struct {
+	Version   int             `json:"version"`
+	Schema    json.RawMessage `json:"schema"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// EventTypeParams is the body of requests creating or updating an event type.
+type EventTypeParams struct {
+	Name        string          `json:"name,omitempty"`
+	Description string          `json:"description"`
+	Schema      json.RawMessage `json:"schema,omitempty"`
+}
+
+func (c *Client) ListEventTypes(ctx context.Context, opts ListOptions) ([]EventType, *Pagination, error) {
+	var eventTypes []EventType
+
+	p, err := c.list(ctx, c.accountPath("/event-types"), opts.values(), &eventTypes)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return eventTypes, p, nil
+}
+
+// GetEventType fetches an event type by ID or name.
+func (c *Client) GetEventType(ctx context.Context, id string) (*EventType, error) {
+	var eventType EventType
+	if err := c.get(ctx, c.accountPath("/event-types/%s", url.PathEscape(id)), &eventType); err != nil {
+		return nil, err
+	}
+
+	return &eventType, nil
+}
+
+func (c *Client) CreateEventType(ctx context.Context, params EventTypeParams) (*EventType, error) {
+	var eventType EventType
+	if err := c.call(ctx, http.MethodPost, c.accountPath("/event-types"), params, &eventType); err != nil {
+		return nil, err
+	}
+
+	return &eventType, nil
+}
+
+// UpdateEventType updates the description and schema of an event type. Its
+// name cannot be changed.
+func (c *Client) UpdateEventType(ctx context.Context, id string, params EventTypeParams) (*EventType, error) {
+	var eventType EventType
+	if err := c.call(ctx, http.MethodPatch, c.accountPath("/event-types/%s", url.PathEscape(id)), params, &eventType); err != nil {
+		return nil, err
+	}
+
+	return &eventType, nil
+}
+
+func (c *Client) DeleteEventType(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, c.accountPath("/event-types/%s", url.PathEscape(id)), nil, nil)
+}
+
+// ListSchemaVersions lists the schema versions of an event type, oldest
+// first.
+func (c *Client) ListSchemaVersions(ctx context.Context, id string, opts ListOptions) ([]SchemaVersion, *Pagination, error) {
+	var versions []SchemaVersion
+
+	p, err := c.list(ctx, c.accountPath("/event-types/%s/schemas", url.PathEscape(id)), opts.values(), &versions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return versions, p, nil
+}
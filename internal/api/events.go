@@ -0,0 +1,289 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	EventStatusScheduled = "scheduled"
+	EventStatusPending   = "pending"
+	EventStatusDelivered = "delivered"
+	EventStatusFailed    = "failed"
+	EventStatusCancelled = "cancelled"
+)
+
+type Event struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	Status    string          `json:"status"`
+	DeliverAt *time.Time      `json:"deliver_at,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+}
+
+// EventParams is the body of requests creating an event. Events created
+// with an idempotency key already used are not created again, the existing
+// event is returned instead. Events with a DeliverAt time in the future are
+// scheduled and only delivered from then on.
+type EventParams struct {
+	Type           string          `json:"type"`
+	Data           json.RawMessage `json:"data"`
+	IdempotencyKey string          `json:"idempotency_key,omitempty"`
+	DeliverAt      *time.Time      `json:"deliver_at,omitempty"`
+}
+
+// MaxEventBatchSize is the largest number of events CreateEvents accepts.
+const MaxEventBatchSize = 100
+
+// EventBatchResult is the outcome of creating one event of a batch: either
+// the event or the error it was rejected with.
+type EventBatchResult struct {
+	Event *Event `json:"event,omitempty"`
+	Error *Error `json:"error,omitempty"`
+}
+
+const (
+	AttemptStatusSucceeded = "succeeded"
+	AttemptStatusFailed    = "failed"
+)
+
+// Attempt is one try at delivering an event to a webhook.
+type Attempt struct {
+	ID             string     `json:"id"`
+	EventID        string     `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	WebhookID      string     `json:"webhook_id"`
+	URL            string     `json:"url"`
+	Status         string     `json:"status"`
+	ResponseStatus int        `json:"response_status,omitempty"`
+	ResponseBody   string     `json:"response_body,omitempty"`
+	Error          string     `json:"error,omitempty"`
+	DurationMS     int64      `json:"duration_ms"`
+	CreatedAt      time.Time  `json:"created_at"`
+	NextRetryAt    *time.Time `json:"next_retry_at,omitempty"`
+}
+
+// EventListOptions filters the events returned by ListEvents.
+type EventListOptions struct {
+	ListOptions
+	// Type matches event types, and may be a pattern such as "order.*".
+	Type      string
+	Status    string
+	WebhookID string
+	// CreatedAfter only returns events created after the given time.
+	CreatedAfter time.Time
+	// UpdatedAfter only returns events created or whose status changed
+	// after the given time.
+	UpdatedAfter time.Time
+}
+
+func (o EventListOptions) values() url.Values {
+	v := o.ListOptions.values()
+	setNonEmpty(v, "type", o.Type)
+	setNonEmpty(v, "status", o.Status)
+	setNonEmpty(v, "webhook_id", o.WebhookID)
+
+	if !o.CreatedAfter.IsZero() {
+		v.Set("created_after", o.CreatedAfter.UTC().Format(time.RFC3339Nano))
+	}
+
+	if !o.UpdatedAfter.IsZero() {
+		v.Set("updated_after", o.UpdatedAfter.UTC().Format(time.RFC3339Nano))
+	}
+
+	return v
+}
+
+func (c *Client) ListEvents(ctx context.Context, opts EventListOptions) ([]Event, *Pagination, error) {
+	var events []Event
+
+	p, err := c.list(ctx, c.accountPath("/events"), opts.values(), &events)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return events, p, nil
+}
+
+// AttemptListOptions filters the attempts returned by ListAttempts.
+type AttemptListOptions struct {
+	ListOptions
+	EventType    string
+	Status       string
+	WebhookID    string
+	CreatedAfter time.Time
+}
+
+func (o AttemptListOptions) values() url.Values {
+	v := o.ListOptions.values()
+	setNonEmpty(v, "event_type", o.EventType)
+	setNonEmpty(v, "status", o.Status)
+	setNonEmpty(v, "webhook_id", o.WebhookID)
+
+	if !o.CreatedAfter.IsZero() {
+		v.Set("created_after", o.CreatedAfter.UTC().Format(time.RFC3339Nano))
+	}
+
+	return v
+}
+
+// ListAttempts lists the delivery attempts of every event of the account.
+func (c *Client) ListAttempts(ctx context.Context, opts AttemptListOptions) ([]Attempt, *Pagination, error) {
+	var attempts []Attempt
+
+	p, err := c.list(ctx, c.accountPath("/attempts"), opts.values(), &attempts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attempts, p, nil
+}
+
+func setNonEmpty(v url.Values, key, value string) {
+	if value != "" {
+		v.Set(key, value)
+	}
+}
+
+func (c *Client) GetEvent(ctx context.Context, id string) (*Event, error) {
+	var event Event
+	if err := c.get(ctx, c.accountPath("/events/%s", url.PathEscape(id)), &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// ListEventAttempts lists the delivery attempts of an event.
+func (c *Client) ListEventAttempts(ctx context.Context, eventID string, opts AttemptListOptions) ([]Attempt, *Pagination, error) {
+	var attempts []Attempt
+
+	p, err := c.list(ctx, c.accountPath("/events/%s/attempts", url.PathEscape(eventID)), opts.values(), &attempts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attempts, p, nil
+}
+
+// ResendEvent delivers an event again to every subscribed webhook.
+func (c *Client) ResendEvent(ctx context.Context, id string) (*Event, error) {
+	var event Event
+	if err := c.call(ctx, http.MethodPost, c.accountPath("/events/%s/resend", url.PathEscape(id)), nil, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// ResendEventToWebhook delivers an event again to a single webhook, leaving
+// the deliveries to the other webhooks alone.
+func (c *Client) ResendEventToWebhook(ctx context.Context, id, webhookID string) (*Event, error) {
+	body := struct {
+		WebhookID string `json:"webhook_id"`
+	}{webhookID}
+
+	var event Event
+	if err := c.call(ctx, http.MethodPost, c.accountPath("/events/%s/resend", url.PathEscape(id)), body, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// CancelEvent cancels a pending or scheduled event, stopping its delivery.
+func (c *Client) CancelEvent(ctx context.Context, id string) (*Event, error) {
+	var event Event
+	if err := c.call(ctx, http.MethodPost, c.accountPath("/events/%s/cancel", url.PathEscape(id)), nil, &event); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+func (c *Client) CreateEvent(ctx context.Context, params EventParams) (*Event, error) {
+	req, err := c.NewRequest(ctx, http.MethodPost, c.accountPath("/events"), params)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.IdempotencyKey != "" {
+		req.Header.Set(HeaderIdempotencyKey, params.IdempotencyKey)
+	}
+
+	var event Event
+	if _, err := c.Do(req, &envelope{Data: &event}); err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
+
+// CreateEvents creates up to MaxEventBatchSize events in one request. The
+// results are in the order of events. Events rejected by the API are reported
+// in their result, the returned error is for the request as a whole.
+func (c *Client) CreateEvents(ctx context.Context, events []EventParams) ([]EventBatchResult, error) {
+	body := struct {
+		Events []EventParams `json:"events"`
+	}{events}
+
+	req, err := c.NewRequest(ctx, http.MethodPost, c.accountPath("/events/batch"), body)
+	if err != nil {
+		return nil, err
+	}
+
+	// When every event has an idempotency key the batch can safely be sent
+	// again, so let it be retried.
+	if key := batchIdempotencyKey(events); key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+
+	var results []EventBatchResult
+	if _, err := c.Do(req, &envelope{Data: &results}); err != nil {
+		return nil, err
+	}
+
+	if len(results) != len(events) {
+		return nil, fmt.Errorf("expected %d results, got %d", len(events), len(results))
+	}
+
+	return results, nil
+}
+
+func batchIdempotencyKey(events []EventParams) string {
+	h := sha256.New()
+
+	for _, ev := range events {
+		if ev.IdempotencyKey == "" {
+			return ""
+		}
+
+		fmt.Fprintln(h, ev.IdempotencyKey)
+	}
+
+	return "batch_" + hex.EncodeToString(h.Sum(nil))
+}
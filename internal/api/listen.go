@@ -0,0 +1,195 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	mediaTypeEventStream = "text/event-stream"
+
+	sseEventDelivery = "delivery"
+	maxSSELineSize   = 4 << 20
+)
+
+// Delivery is a webhook delivery relayed to a listening CLI instead of
+// being posted to a public endpoint.
+type Delivery struct {
+	ID        string            `json:"id"`
+	EventID   string            `json:"event_id"`
+	EventType string            `json:"event_type"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Payload   json.RawMessage   `json:"payload"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// ListenOptions selects the deliveries relayed by Listen.
+type ListenOptions struct {
+	// EventTypes restricts the stream to the given event types. All event
+	// types are relayed when empty.
+	EventTypes []string
+	// LastDeliveryID resumes the stream after the given delivery.
+	LastDeliveryID string
+}
+
+// Listen opens a stream of deliveries and calls fn for each of them, in
+// order, until the stream ends, ctx is done or fn returns an error. The ID
+// of the last delivery handled is returned so that callers can resume.
+func (c *Client) Listen(ctx context.Context, opts ListenOptions, fn func(*Delivery) error) (string, error) {
+	lastID := opts.LastDeliveryID
+
+	query := url.Values{}
+	if len(opts.EventTypes) > 0 {
+		query.Set("types", strings.Join(opts.EventTypes, ","))
+	}
+
+	path := c.accountPath("/deliveries/stream")
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return lastID, err
+	}
+
+	req.Header.Set("Accept", mediaTypeEventStream)
+	if lastID != "" {
+		req.Header.Set("Last-Event-ID", lastID)
+	}
+
+	// The stream stays open indefinitely, so the request timeout must not
+	// apply to it.
+	httpClient := *c.httpClient
+	httpClient.Timeout = 0
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return lastID, ctxErr
+		}
+
+		return lastID, &NetworkError{Err: err}
+	}
+
+	defer resp.Body.Close()
+
+	if err := checkResponse(resp); err != nil {
+		return lastID, err
+	}
+
+	err = readEvents(resp.Body, func(ev sseEvent) error {
+		if ev.Event != sseEventDelivery {
+			return nil
+		}
+
+		var delivery Delivery
+		if err := json.Unmarshal(ev.Data, &delivery); err != nil {
+			return fmt.Errorf("decoding delivery: %w", err)
+		}
+
+		if err := fn(&delivery); err != nil {
+			return err
+		}
+
+		lastID = ev.ID
+		if lastID == "" {
+			lastID = delivery.ID
+		}
+
+		return nil
+	})
+
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return lastID, ctxErr
+	}
+
+	return lastID, err
+}
+
+type sseEvent struct {
+	ID    string
+	Event string
+	Data  []byte
+}
+
+// readEvents parses a server-sent events stream, calling fn for each
+// complete event. Comments and retry hints are ignored.
+func readEvents(r io.Reader, fn func(sseEvent) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxSSELineSize)
+
+	var (
+		ev   sseEvent
+		data bytes.Buffer
+	)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line == "" {
+			if data.Len() > 0 {
+				ev.Data = bytes.TrimSuffix(data.Bytes(), []byte("\n"))
+				if ev.Event == "" {
+					ev.Event = "message"
+				}
+
+				if err := fn(ev); err != nil {
+					return err
+				}
+			}
+
+			ev = sseEvent{}
+			data.Reset()
+
+			continue
+		}
+
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "id":
+			ev.ID = value
+		case "event":
+			ev.Event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteString("\n")
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &NetworkError{Err: err}
+	}
+
+	return nil
+}
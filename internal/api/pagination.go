@@ -0,0 +1,57 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"net/url"
+	"strconv"
+)
+
+const DefaultPerPage = 100
+
+// ListOptions selects a page of a collection.
+type ListOptions struct {
+	Page    int
+	PerPage int
+}
+
+func (o ListOptions) values() url.Values {
+	v := url.Values{}
+
+	if o.Page > 0 {
+		v.Set("page", strconv.Itoa(o.Page))
+	}
+
+	if o.PerPage > 0 {
+		v.Set("per_page", strconv.Itoa(o.PerPage))
+	}
+
+	return v
+}
+
+// Pagination describes the page returned by a list endpoint.
+type Pagination struct {
+	CurrentPage  int `json:"current_page"`
+	PerPage      int `json:"per_page"`
+	TotalEntries int `json:"total_entries"`
+	TotalPages   int `json:"total_pages"`
+}
+
+// HasNext reports whether there are pages after this one.
+func (p Pagination) HasNext() bool {
+	return p.CurrentPage < p.TotalPages
+}
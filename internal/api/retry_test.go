@@ -0,0 +1,433 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+)
+
+// stubServer answers with the given statuses in turn, then with 200. It
+// counts the requests it receives and records their bodies.
+type stubServer struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	statuses []int
+	header   http.Header
+	calls    int32
+	bodies   []string
+}
+
+func newStubServer(t *testing.T, header http.Header, statuses ...int) *stubServer {
+	t.Helper()
+
+	s := &stubServer{statuses: statuses, header: header}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&s.calls, 1)
+		body, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+
+		status := http.StatusOK
+		if int(n) <= len(s.statuses) {
+			status = s.statuses[n-1]
+		}
+
+		if status != http.StatusOK {
+			for name, values := range s.header {
+				w.Header()[name] = values
+			}
+		}
+
+		w.Header().Set("Content-Type", mediaTypeJSON)
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"data":{"id":"evt_1"}}`)
+	}))
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+func (s *stubServer) Calls() int {
+	return int(atomic.LoadInt32(&s.calls))
+}
+
+// waits records the delays the retry transport would have slept for,
+// without sleeping.
+type waits struct {
+	mu     sync.Mutex
+	delays []time.Duration
+}
+
+func (w *waits) option() Option {
+	return func(c *Client) {
+		if t, ok := c.httpClient.Transport.(*retryTransport); ok {
+			t.sleep = func(req *http.Request, d time.Duration) error {
+				w.mu.Lock()
+				defer w.mu.Unlock()
+
+				w.delays = append(w.delays, d)
+
+				return req.Context().Err()
+			}
+		}
+	}
+}
+
+func newTestClient(t *testing.T, srv *stubServer, opts ...Option) *Client {
+	t.Helper()
+
+	c, err := NewClient(&config.Config{Account: "acc_1", AccessToken: "t", BaseURL: srv.URL}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
+func send(c *Client, ctx context.Context, method, key string) error {
+	var body interface{}
+	if method != http.MethodGet {
+		body = map[string]string{"type": "order.created"}
+	}
+
+	req, err := c.NewRequest(ctx, method, c.accountPath("/events"), body)
+	if err != nil {
+		return err
+	}
+
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+
+	_, err = c.Do(req, &envelope{Data: &Event{}})
+
+	return err
+}
+
+func TestRetryTransientStatus(t *testing.T) {
+	for _, status := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusTooManyRequests} {
+		status := status
+
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			srv := newStubServer(t, nil, status, status)
+			w := &waits{}
+			c := newTestClient(t, srv, WithRetries(3), w.option())
+
+			if err := send(c, context.Background(), http.MethodGet, ""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := srv.Calls(); got != 3 {
+				t.Errorf("got %d requests, want 3", got)
+			}
+
+			if len(w.delays) != 2 {
+				t.Errorf("got %d waits, want 2", len(w.delays))
+			}
+		})
+	}
+}
+
+func TestRetryDoesNotRetryOtherStatuses(t *testing.T) {
+	for _, status := range []int{http.StatusBadRequest, http.StatusNotFound, http.StatusInternalServerError} {
+		status := status
+
+		t.Run(strconv.Itoa(status), func(t *testing.T) {
+			srv := newStubServer(t, nil, status)
+			c := newTestClient(t, srv, WithRetries(3), (&waits{}).option())
+
+			if err := send(c, context.Background(), http.MethodGet, ""); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if got := srv.Calls(); got != 1 {
+				t.Errorf("got %d requests, want 1", got)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		min, max time.Duration
+	}{
+		{name: "seconds", value: "7", min: 7 * time.Second, max: 7 * time.Second},
+		{name: "zero seconds", value: "0", min: 0, max: 0},
+		{
+			name:  "http date",
+			value: time.Now().Add(30 * time.Second).UTC().Format(http.TimeFormat),
+			min:   28 * time.Second,
+			max:   30 * time.Second,
+		},
+		{
+			name:  "http date in the past",
+			value: time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat),
+			min:   0,
+			max:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStubServer(t, http.Header{"Retry-After": {tt.value}}, http.StatusServiceUnavailable)
+			w := &waits{}
+			c := newTestClient(t, srv, WithRetries(1), w.option())
+
+			if err := send(c, context.Background(), http.MethodGet, ""); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(w.delays) != 1 {
+				t.Fatalf("got %d waits, want 1", len(w.delays))
+			}
+
+			if d := w.delays[0]; d < tt.min || d > tt.max {
+				t.Errorf("waited %v, want between %v and %v", d, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRetryAfterBeyondLimitGivesUp(t *testing.T) {
+	srv := newStubServer(t, http.Header{"Retry-After": {"3600"}}, http.StatusTooManyRequests)
+	w := &waits{}
+	c := newTestClient(t, srv, WithRetries(3), w.option())
+
+	err := send(c, context.Background(), http.MethodGet, "")
+	if !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got %v, want a rate limit error", err)
+	}
+
+	if got := srv.Calls(); got != 1 {
+		t.Errorf("got %d requests, want 1", got)
+	}
+}
+
+func TestRetryAttemptCap(t *testing.T) {
+	for _, retries := range []int{0, 1, 4} {
+		retries := retries
+
+		t.Run(strconv.Itoa(retries), func(t *testing.T) {
+			statuses := make([]int, 10)
+			for i := range statuses {
+				statuses[i] = http.StatusServiceUnavailable
+			}
+
+			srv := newStubServer(t, nil, statuses...)
+			c := newTestClient(t, srv, WithRetries(retries), (&waits{}).option())
+
+			err := send(c, context.Background(), http.MethodGet, "")
+			if !errors.Is(err, ErrServer) {
+				t.Fatalf("got %v, want a server error", err)
+			}
+
+			if got := srv.Calls(); got != retries+1 {
+				t.Errorf("got %d requests, want %d", got, retries+1)
+			}
+		})
+	}
+}
+
+func TestRetryStopsWhenTimeoutEnds(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []Option
+		ctx  func() (context.Context, context.CancelFunc)
+	}{
+		{
+			name: "client timeout",
+			opts: []Option{WithTimeout(200 * time.Millisecond), WithRetries(5)},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithCancel(context.Background())
+			},
+		},
+		{
+			name: "context deadline",
+			opts: []Option{WithRetries(5)},
+			ctx: func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), 200*time.Millisecond)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			srv := newStubServer(t, http.Header{"Retry-After": {"10"}},
+				http.StatusServiceUnavailable, http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+			c := newTestClient(t, srv, tt.opts...)
+
+			ctx, cancel := tt.ctx()
+			defer cancel()
+
+			start := time.Now()
+
+			if err := send(c, ctx, http.MethodGet, ""); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("gave up after %v, want about 200ms", elapsed)
+			}
+
+			if got := srv.Calls(); got != 1 {
+				t.Errorf("got %d requests, want 1", got)
+			}
+		})
+	}
+}
+
+func TestRetryNonIdempotentRequests(t *testing.T) {
+	tests := []struct {
+		method string
+		key    string
+		calls  int
+	}{
+		{method: http.MethodPost, calls: 1},
+		{method: http.MethodPatch, calls: 1},
+		{method: http.MethodPost, key: "key_1", calls: 2},
+		{method: http.MethodPatch, key: "key_1", calls: 2},
+		{method: http.MethodPut, calls: 2},
+		{method: http.MethodDelete, calls: 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.method+" "+tt.key, func(t *testing.T) {
+			srv := newStubServer(t, nil, http.StatusServiceUnavailable)
+			c := newTestClient(t, srv, WithRetries(3), (&waits{}).option())
+
+			err := send(c, context.Background(), tt.method, tt.key)
+			if tt.calls == 1 && !errors.Is(err, ErrServer) {
+				t.Fatalf("got %v, want a server error", err)
+			}
+
+			if tt.calls > 1 && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if got := srv.Calls(); got != tt.calls {
+				t.Errorf("got %d requests, want %d", got, tt.calls)
+			}
+
+			for i, body := range srv.bodies {
+				if body != srv.bodies[0] {
+					t.Errorf("request %d sent body %q, want %q", i+1, body, srv.bodies[0])
+				}
+			}
+		})
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTransportsLeaveTheRequestUntouched(t *testing.T) {
+	var bodies []string
+
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		bodies = append(bodies, string(body))
+
+		status := http.StatusServiceUnavailable
+		if len(bodies) > 1 {
+			status = http.StatusOK
+		}
+
+		return &http.Response{StatusCode: status, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+	})
+
+	var trace bytes.Buffer
+
+	transport := &retryTransport{
+		next:    &traceTransport{next: next, out: &trace, level: TraceDebug},
+		retries: 1,
+		sleep:   func(*http.Request, time.Duration) error { return nil },
+	}
+
+	req, err := http.NewRequest(http.MethodPut, "http://example.com", bytes.NewReader([]byte("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := req.Body
+
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if req.Body != body {
+		t.Error("the request body was replaced")
+	}
+
+	if want := []string{"payload", "payload"}; len(bodies) != 2 || bodies[0] != want[0] || bodies[1] != want[1] {
+		t.Errorf("sent bodies %q, want %q", bodies, want)
+	}
+
+	// Without GetBody, the trace has to read the body itself.
+	req, err = http.NewRequest(http.MethodGet, "http://example.com", io.NopCloser(bytes.NewReader([]byte("query"))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body = req.Body
+	bodies = bodies[:1]
+
+	tr := &traceTransport{next: next, out: &trace, level: TraceDebug}
+	if _, err := tr.RoundTrip(req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Body != body {
+		t.Error("the request body was replaced")
+	}
+
+	if bodies[1] != "query" {
+		t.Errorf("sent body %q, want %q", bodies[1], "query")
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Subscription delivers the events of a type to a webhook. EventType may be
+// a pattern such as "order.*".
+type Subscription struct {
+	ID        string    `json:"id"`
+	WebhookID string    `json:"webhook_id"`
+	EventType string    `json:"event_type"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SubscriptionParams is the body of requests creating a subscription. The
+// event type may be a pattern such as "order.*".
+type SubscriptionParams struct {
+	WebhookID string `json:"webhook_id"`
+	EventType string `json:"event_type"`
+}
+
+type SubscriptionListOptions struct {
+	ListOptions
+	WebhookID string
+}
+
+func (o SubscriptionListOptions) values() url.Values {
+	v := o.ListOptions.values()
+	setNonEmpty(v, "webhook_id", o.WebhookID)
+
+	return v
+}
+
+func (c *Client) ListSubscriptions(ctx context.Context, opts SubscriptionListOptions) ([]Subscription, *Pagination, error) {
+	var subscriptions []Subscription
+
+	p, err := c.list(ctx, c.accountPath("/subscriptions"), opts.values(), &subscriptions)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return subscriptions, p, nil
+}
+
+func (c *Client) GetSubscription(ctx context.Context, id string) (*Subscription, error) {
+	var subscription Subscription
+	if err := c.get(ctx, c.accountPath("/subscriptions/%s", url.PathEscape(id)), &subscription); err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (c *Client) CreateSubscription(ctx context.Context, params SubscriptionParams) (*Subscription, error) {
+	var subscription Subscription
+	if err := c.call(ctx, http.MethodPost, c.accountPath("/subscriptions"), params, &subscription); err != nil {
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (c *Client) DeleteSubscription(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, c.accountPath("/subscriptions/%s", url.PathEscape(id)), nil, nil)
+}
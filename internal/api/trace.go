@@ -0,0 +1,254 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TraceLevel int
+
+const (
+	// TraceOff disables tracing.
+	TraceOff TraceLevel = iota
+	// TraceVerbose logs the method, URL, status and duration of each request.
+	TraceVerbose
+	// TraceDebug additionally dumps headers and bodies.
+	TraceDebug
+)
+
+const redacted = "[REDACTED]"
+
+// redactedHeaders are never written to the trace in clear text.
+var redactedHeaders = map[string]struct{}{
+	"Authorization":       {},
+	"Cookie":              {},
+	"Set-Cookie":          {},
+	"X-Xigubo-Signature":  {},
+	"Proxy-Authorization": {},
+}
+
+// WithTrace logs requests and responses to w. It must be given after
+// WithHTTPClient, as it wraps the transport of the client in place.
+func WithTrace(w io.Writer, level TraceLevel) Option {
+	return func(c *Client) {
+		if level == TraceOff {
+			return
+		}
+
+		transport := c.httpClient.Transport
+		if transport == nil {
+			transport = http.DefaultTransport
+		}
+
+		c.httpClient.Transport = &traceTransport{
+			next:  transport,
+			out:   w,
+			level: level,
+		}
+	}
+}
+
+type traceTransport struct {
+	next  http.RoundTripper
+	out   io.Writer
+	level TraceLevel
+	mu    sync.Mutex
+}
+
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqDump string
+	if t.level >= TraceDebug {
+		var body []byte
+		var err error
+
+		req, body, err = peekRequestBody(req)
+		if err != nil {
+			return nil, err
+		}
+
+		reqDump = dumpMessage(req.Header, body)
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fmt.Fprintf(t.out, "> %s %s\n", req.Method, req.URL.Redacted())
+	if t.level >= TraceDebug {
+		fmt.Fprint(t.out, prefixLines(reqDump, "> "))
+	}
+
+	if err != nil {
+		fmt.Fprintf(t.out, "< error after %s: %v\n", elapsed, err)
+		return nil, err
+	}
+
+	fmt.Fprintf(t.out, "< %s (%s)\n", resp.Status, elapsed)
+
+	if t.level >= TraceDebug {
+		var body []byte
+		if !isStream(resp.Header) {
+			body, err = peekResponseBody(resp)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		fmt.Fprint(t.out, prefixLines(dumpMessage(resp.Header, body), "< "))
+	}
+
+	return resp, nil
+}
+
+func isStream(h http.Header) bool {
+	return strings.HasPrefix(h.Get("Content-Type"), "text/event-stream")
+}
+
+// peekRequestBody reads the body of req, returning the request to send in
+// its place. The body is read from a copy when req can provide one, and
+// otherwise the request is cloned, leaving the one given untouched.
+func peekRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+
+	if req.GetBody != nil {
+		rc, err := req.GetBody()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rc.Close()
+
+		body, err := io.ReadAll(rc)
+
+		return req, body, err
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req.Body.Close()
+
+	clone := req.Clone(req.Context())
+	clone.Body = io.NopCloser(bytes.NewReader(body))
+
+	return clone, body, nil
+}
+
+func peekResponseBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	return body, nil
+}
+
+func dumpMessage(header http.Header, body []byte) string {
+	var b strings.Builder
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			if _, ok := redactedHeaders[http.CanonicalHeaderKey(name)]; ok {
+				value = redacted
+			}
+
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	if len(body) > 0 {
+		b.WriteString("\n")
+		b.Write(redactBody(body))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// redactBody masks the value of every JSON member whose name mentions a
+// secret. Bodies that are not JSON are returned untouched.
+func redactBody(body []byte) []byte {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return bytes.TrimRight(body, "\n")
+	}
+
+	redacted, err := json.MarshalIndent(redactValue(v), "", "  ")
+	if err != nil {
+		return body
+	}
+
+	return redacted
+}
+
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if strings.Contains(strings.ToLower(key), "secret") {
+				v[key] = redacted
+				continue
+			}
+
+			v[key] = redactValue(value)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactValue(v[i])
+		}
+	}
+
+	return v
+}
+
+func prefixLines(s, prefix string) string {
+	if s == "" {
+		return ""
+	}
+
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	for i := range lines {
+		lines[i] = prefix + lines[i]
+	}
+
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type Webhook struct {
+	ID          string    `json:"id"`
+	URL         string    `json:"url"`
+	Description string    `json:"description,omitempty"`
+	Secret      string    `json:"secret,omitempty"`
+	Disabled    bool      `json:"disabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// WebhookParams is the body of requests creating or updating a webhook. An
+// empty secret lets the API generate one, or keeps the current one.
+type WebhookParams struct {
+	URL         string `json:"url,omitempty"`
+	Description string `json:"description"`
+	Secret      string `json:"secret,omitempty"`
+	Disabled    bool   `json:"disabled"`
+}
+
+func (c *Client) ListWebhooks(ctx context.Context, opts ListOptions) ([]Webhook, *Pagination, error) {
+	var webhooks []Webhook
+
+	p, err := c.list(ctx, c.accountPath("/webhooks"), opts.values(), &webhooks)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return webhooks, p, nil
+}
+
+func (c *Client) GetWebhook(ctx context.Context, id string) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.get(ctx, c.accountPath("/webhooks/%s", url.PathEscape(id)), &webhook); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+// ListWebhookAttempts lists the delivery attempts made to a webhook.
+func (c *Client) ListWebhookAttempts(ctx context.Context, webhookID string, opts AttemptListOptions) ([]Attempt, *Pagination, error) {
+	var attempts []Attempt
+
+	p, err := c.list(ctx, c.accountPath("/webhooks/%s/attempts", url.PathEscape(webhookID)), opts.values(), &attempts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attempts, p, nil
+}
+
+func (c *Client) CreateWebhook(ctx context.Context, params WebhookParams) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.call(ctx, http.MethodPost, c.accountPath("/webhooks"), params, &webhook); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (c *Client) UpdateWebhook(ctx context.Context, id string, params WebhookParams) (*Webhook, error) {
+	var webhook Webhook
+	if err := c.call(ctx, http.MethodPatch, c.accountPath("/webhooks/%s", url.PathEscape(id)), params, &webhook); err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func (c *Client) DeleteWebhook(ctx context.Context, id string) error {
+	return c.call(ctx, http.MethodDelete, c.accountPath("/webhooks/%s", url.PathEscape(id)), nil, nil)
+}
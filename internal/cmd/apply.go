@@ -0,0 +1,278 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/manifest"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagFile   = "file"
+	flagDryRun = "dry-run"
+	flagPrune  = "prune"
+
+	fieldValueWidth = 60
+)
+
+func NewCmdApply(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "apply",
+		Short: "Converge event types, webhooks and subscriptions to a manifest",
+		Long: heredoc.Doc(`
+			Read a manifest of event types, webhooks and subscriptions, compare it
+			with the account and create, update and, with --prune, delete resources
+			until they match.
+
+			Event types are identified by name and webhooks by URL, so changing the
+			URL of a webhook in the manifest creates a new webhook. Webhook secrets
+			may reference environment variables, as in $ORDERS_WEBHOOK_SECRET.
+
+			Schema updates that would break consumers or producers of an event
+			type are refused unless --force is given.
+		`),
+		Args: cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo apply -f xigubo.yaml --dry-run
+			xibugo apply -f xigubo.yaml --prune --yes
+			xibugo export | xibugo apply -f - --env sandbox
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			m, err := readManifest(cmd, viper.GetString(flagFile))
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			state, err := manifest.Fetch(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+
+			plan, err := manifest.Compute(m, state, viper.GetBool(flagPrune))
+			if err != nil {
+				return err
+			}
+
+			if viper.GetBool(flagDryRun) || plan.Empty() {
+				if ok, err := printStructured(cmd.OutOrStdout(), plan); ok {
+					return err
+				}
+
+				printPlan(cmd.OutOrStdout(), plan)
+
+				return nil
+			}
+
+			printPlan(cmd.ErrOrStderr(), plan)
+
+			if err := checkBreakingSchemas(cmd, plan); err != nil {
+				return err
+			}
+
+			if !viper.GetBool(flagYes) {
+				if !isInteractive(cmd) {
+					return &UsageError{Err: errors.New("confirmation required, pass --yes to apply without prompting")}
+				}
+
+				ok, err := promptConfirmation("Apply these changes?", false)
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					return errors.New("did not confirm")
+				}
+			}
+
+			structured := outputFormat() == formatJSON || outputFormat() == formatYAML
+
+			err = plan.Apply(cmd.Context(), client, func(c manifest.Change) {
+				if c.Kind == manifest.KindEventType {
+					forgetSchema(cfg, c.Name)
+				}
+
+				if !structured {
+					cmd.Printf("%s %s\n", pastTense(c.Action), c)
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), plan); ok {
+				return err
+			}
+
+			cmd.Printf(
+				"Applied: %d created, %d updated, %d deleted.\n",
+				plan.Count(manifest.ActionCreate),
+				plan.Count(manifest.ActionUpdate),
+				plan.Count(manifest.ActionDelete),
+			)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP(flagFile, "f", "", "Manifest file, or - to read standard input")
+	cmd.Flags().Bool(flagDryRun, false, "Show the plan without changing anything")
+	cmd.Flags().Bool(flagPrune, false, "Delete resources that are not in the manifest")
+	cmd.Flags().BoolP(flagYes, "y", false, "Do not ask for confirmation")
+	cmd.Flags().Bool(flagForce, false, "Apply breaking schema changes")
+	_ = cmd.MarkFlagRequired(flagFile)
+
+	return cmd
+}
+
+// checkBreakingSchemas lists the breaking changes a plan makes to the schemas
+// of event types, and refuses them unless --force is given.
+func checkBreakingSchemas(cmd *cobra.Command, plan *manifest.Plan) error {
+	total := 0
+
+	for _, c := range plan.Changes {
+		changes, err := c.SchemaChanges()
+		if err != nil {
+			return err
+		}
+
+		breaking := schema.Breaking(changes)
+		if len(breaking) == 0 {
+			continue
+		}
+
+		cmd.PrintErrf("\nBreaking schema changes to %s:\n", c.Name)
+		printSchemaChanges(cmd.ErrOrStderr(), breaking)
+
+		total += len(breaking)
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	if !viper.GetBool(flagForce) {
+		cmd.PrintErrln("Pass --force to apply them anyway.")
+
+		return &BreakingChangeError{Changes: total}
+	}
+
+	cmd.PrintErrf("Warning: forcing %s\n", plural(total, "breaking schema change"))
+	plan.AllowBreaking = true
+
+	return nil
+}
+
+func readManifest(cmd *cobra.Command, path string) (*manifest.Manifest, error) {
+	data, err := readData("@"+path, cmd.InOrStdin())
+	if err != nil {
+		return nil, err
+	}
+
+	return manifest.Parse(data)
+}
+
+// printPlan writes the changes of a plan followed by a summary.
+func printPlan(w io.Writer, plan *manifest.Plan) {
+	if plan.Empty() {
+		fmt.Fprintln(w, "No changes, the account matches the manifest.")
+		return
+	}
+
+	printChanges(w, plan)
+
+	fmt.Fprintf(
+		w,
+		"\nPlan: %d to create, %d to update, %d to delete.\n",
+		plan.Count(manifest.ActionCreate),
+		plan.Count(manifest.ActionUpdate),
+		plan.Count(manifest.ActionDelete),
+	)
+}
+
+// printChanges writes the changes of a plan, marking creates with +, updates
+// with ~ and deletes with -.
+func printChanges(w io.Writer, plan *manifest.Plan) {
+	color := colorEnabled(w)
+
+	for _, c := range plan.Changes {
+		symbol, ansi := "+", ansiGreen
+
+		switch c.Action {
+		case manifest.ActionUpdate:
+			symbol, ansi = "~", ansiYellow
+		case manifest.ActionDelete:
+			symbol, ansi = "-", ansiRed
+		}
+
+		if color {
+			symbol = ansi + symbol + ansiReset
+		}
+
+		fmt.Fprintf(w, "%s %s\n", symbol, c)
+
+		for _, f := range c.Fields {
+			fmt.Fprintf(w, "    %s: %s => %s\n", f.Field, quoteField(f.Old), quoteField(f.New))
+		}
+	}
+}
+
+func quoteField(s string) string {
+	if s == manifest.Sensitive {
+		return s
+	}
+
+	return fmt.Sprintf("%q", snippet(s, fieldValueWidth))
+}
+
+func pastTense(action manifest.Action) string {
+	switch action {
+	case manifest.ActionCreate:
+		return "Created"
+	case manifest.ActionUpdate:
+		return "Updated"
+	case manifest.ActionDelete:
+		return "Deleted"
+	}
+
+	return string(action)
+}
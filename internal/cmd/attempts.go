@@ -0,0 +1,130 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/spf13/cobra"
+)
+
+const (
+	flagFailedOnly = "failed-only"
+
+	snippetWidth = 60
+)
+
+type listAttemptsFunc func(ctx context.Context, opts api.AttemptListOptions) ([]api.Attempt, *api.Pagination, error)
+
+// collectAttempts fetches every page of attempts.
+func collectAttempts(ctx context.Context, list listAttemptsFunc, opts api.AttemptListOptions) ([]api.Attempt, error) {
+	var all []api.Attempt
+
+	for page := 1; ; page++ {
+		opts.Page = page
+
+		attempts, p, err := list(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, attempts...)
+
+		if !p.HasNext() {
+			return all, nil
+		}
+	}
+}
+
+// printAttempts writes attempts as a table. The target column shows the
+// webhook of each attempt, or its event when byEvent is false.
+func printAttempts(cmd *cobra.Command, attempts []api.Attempt, byEvent bool) error {
+	if ok, err := printStructured(cmd.OutOrStdout(), attempts); ok {
+		return err
+	}
+
+	color := colorEnabled(cmd.OutOrStdout())
+	target := "WEBHOOK"
+
+	if !byEvent {
+		target = "EVENT"
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\t%s\tSTATUS\tHTTP\tLATENCY\tNEXT RETRY\tRESPONSE\n", target)
+
+	for _, a := range attempts {
+		id := a.WebhookID
+		if !byEvent {
+			id = a.EventID
+		}
+
+		httpStatus := "-"
+		if a.ResponseStatus != 0 {
+			httpStatus = fmt.Sprint(a.ResponseStatus)
+		}
+
+		nextRetry := "-"
+		if a.NextRetryAt != nil {
+			nextRetry = a.NextRetryAt.Local().Format(time.RFC3339)
+		}
+
+		response := a.ResponseBody
+		if a.Error != "" {
+			response = a.Error
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%dms\t%s\t%s\n",
+			a.CreatedAt.Local().Format(time.RFC3339),
+			id,
+			colorStatus(a.Status, color),
+			httpStatus,
+			a.DurationMS,
+			nextRetry,
+			snippet(response, snippetWidth),
+		)
+	}
+
+	return w.Flush()
+}
+
+// snippet collapses s to a single line of at most n runes.
+func snippet(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+
+	if r := []rune(s); len(r) > n {
+		return string(r[:n-1]) + "…"
+	}
+
+	return s
+}
+
+func attemptListOptions(failedOnly bool) api.AttemptListOptions {
+	var opts api.AttemptListOptions
+	if failedOnly {
+		opts.Status = api.AttemptStatusFailed
+	}
+
+	return opts
+}
@@ -0,0 +1,44 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/spf13/viper"
+)
+
+func newClient(cfg *config.Config, opts *internal.CommandOptions) (*api.Client, error) {
+	return api.NewClient(
+		cfg,
+		api.WithTrace(opts.Stderr, traceLevel()),
+		api.WithRetries(viper.GetInt(flagRetries)),
+		api.WithTimeout(viper.GetDuration(flagTimeout)),
+	)
+}
+
+func traceLevel() api.TraceLevel {
+	switch {
+	case viper.GetBool(flagDebug):
+		return api.TraceDebug
+	case viper.GetBool(flagVerbose):
+		return api.TraceVerbose
+	}
+
+	return api.TraceOff
+}
@@ -23,8 +23,10 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 
 	"github.com/AlecAivazis/survey/v2"
+	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/getumbeluzi/xibugo-cli/internal"
 	"github.com/getumbeluzi/xibugo-cli/internal/config"
 	"github.com/spf13/cobra"
@@ -39,14 +41,12 @@ const (
 )
 
 var (
-	prodBaseURL    = "https://api.xibugo.com"
-	sandboxBaseURL = "https://api.sandbox.xibugo.com"
-
 	configProps = map[string]struct{}{
 		"account":      {},
 		"base-url":     {},
 		"access-token": {},
 		"sandbox":      {},
+		"env":          {},
 	}
 
 	configPropValidate = map[string]func(string) (interface{}, error){
@@ -62,6 +62,9 @@ var (
 		"access-token": func(value string) (interface{}, error) {
 			return value, nil
 		},
+		"env": func(value string) (interface{}, error) {
+			return value, nil
+		},
 	}
 )
 
@@ -75,6 +78,7 @@ func NewCmdConfig(opts *internal.CommandOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdConfigGet(opts))
 	cmd.AddCommand(NewCmdConfigSet(opts))
 	cmd.AddCommand(NewCmdConfigInit(opts))
+	cmd.AddCommand(NewCmdConfigEnvironments(opts))
 
 	return cmd
 }
@@ -135,6 +139,14 @@ func NewCmdConfigInit(opts *internal.CommandOptions) *cobra.Command {
 				v.Set(flagSandbox, cfg.Sandbox)
 			}
 
+			if cfg.Env != "" {
+				v.Set(flagEnv, cfg.Env)
+			}
+
+			if len(cfg.Environments) > 0 {
+				v.Set(flagEnvironments, environmentsToMap(cfg.Environments))
+			}
+
 			cfgPath := filepath.Join(home, "xibugo", fmt.Sprintf("%s.%s", profile, strings.ToLower(ext)))
 			if err := v.WriteConfigAs(cfgPath); err != nil {
 				return err
@@ -180,6 +192,61 @@ func NewCmdConfigSet(opts *internal.CommandOptions) *cobra.Command {
 	return cmd
 }
 
+func NewCmdConfigEnvironments(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "environments",
+		Short:   "List environments",
+		Aliases: []string{"envs"},
+		Args:    cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo config environments
+		`),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			cfg, err := config.NewWithValidation(false)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "\tNAME\tBASE URL\tCA FILE")
+
+			for _, name := range cfg.EnvironmentNames() {
+				env, _ := cfg.LookupEnvironment(name)
+
+				current := ""
+				if name == cfg.Env {
+					current = "*"
+				}
+
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", current, name, env.BaseURL, env.CAFile)
+			}
+
+			return w.Flush()
+		},
+	}
+
+	return cmd
+}
+
+func environmentsToMap(envs map[string]config.Environment) map[string]interface{} {
+	m := make(map[string]interface{}, len(envs))
+	for name, env := range envs {
+		props := map[string]interface{}{
+			flagBaseURL: env.BaseURL,
+		}
+
+		if env.CAFile != "" {
+			props[flagCAFile] = env.CAFile
+		}
+
+		m[name] = props
+	}
+
+	return m
+}
+
 const (
 	envDev     = "DEV"
 	envSandbox = "SANDBOX"
@@ -187,7 +254,7 @@ const (
 )
 
 func promptConfig(c *config.Config) (*config.Config, string, error) {
-	baseURL := prodBaseURL
+	baseURL := config.Production
 
 	accountID, err := promptAccountID(c.Account)
 	if err != nil {
@@ -199,17 +266,17 @@ func promptConfig(c *config.Config) (*config.Config, string, error) {
 		return nil, "", err
 	}
 
-	env, err := promptEnvironment(envProd)
+	env, err := promptEnvironment(envProd, customEnvironments(c))
 	if err != nil {
 		return nil, "", err
 	}
 
 	if env == envSandbox {
-		baseURL = sandboxBaseURL
+		baseURL = config.Sandbox
 	}
 
 	if env == envDev {
-		baseURL, err = promptBaseURL(prodBaseURL)
+		baseURL, err = promptBaseURL(config.Production)
 		if err != nil {
 			return nil, "", err
 		}
@@ -230,16 +297,19 @@ func promptConfig(c *config.Config) (*config.Config, string, error) {
 	}
 
 	cfg := config.Config{
-		Account:     accountID,
-		AccessToken: accessToken,
+		Account:      accountID,
+		AccessToken:  accessToken,
+		Environments: c.Environments,
 	}
 
-	if env == envDev {
+	switch env {
+	case envProd:
+	case envDev:
 		cfg.BaseURL = baseURL
-	}
-
-	if env == envSandbox {
+	case envSandbox:
 		cfg.Sandbox = true
+	default:
+		cfg.Env = env
 	}
 
 	return &cfg, fileFormat, nil
@@ -273,10 +343,21 @@ func promptAccountID(value string) (string, error) {
 	return accountID, nil
 }
 
-func promptEnvironment(value string) (string, error) {
+func customEnvironments(c *config.Config) []string {
+	var names []string
+	for _, name := range c.EnvironmentNames() {
+		if name != config.EnvProduction && name != config.EnvSandbox {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func promptEnvironment(value string, custom []string) (string, error) {
 	prompt := &survey.Select{
 		Message: "Environment",
-		Options: []string{envProd, envSandbox, envDev},
+		Options: append([]string{envProd, envSandbox, envDev}, custom...),
 		Default: value,
 	}
 
@@ -0,0 +1,349 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/manifest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagFromProfile = "from-profile"
+	flagToProfile   = "to-profile"
+	flagSelector    = "selector"
+	flagRewriteURL  = "rewrite-url"
+	flagCopySecrets = "copy-secrets"
+)
+
+func NewCmdCopy(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy event types, webhooks or subscriptions between profiles",
+		Long: heredoc.Doc(`
+			Copy resources from the account of one profile to the account of
+			another. Resources that already exist in the target, matched by event
+			type name or webhook URL, are updated instead of duplicated.
+		`),
+	}
+
+	cmd.AddCommand(newCmdCopyResource(opts, manifest.KindEventType))
+	cmd.AddCommand(newCmdCopyResource(opts, manifest.KindWebhook))
+	cmd.AddCommand(newCmdCopyResource(opts, manifest.KindSubscription))
+
+	return cmd
+}
+
+func newCmdCopyResource(opts *internal.CommandOptions, kind manifest.Kind) *cobra.Command {
+	var (
+		use      string
+		selected string
+		example  string
+	)
+
+	switch kind {
+	case manifest.KindEventType:
+		use, selected = "event-types", "event type names"
+		example = heredoc.Doc(`
+			xibugo copy event-types --from-profile sandbox --to-profile prod
+			xibugo copy event-types --from-profile sandbox --to-profile prod --selector 'order.*'
+		`)
+	case manifest.KindWebhook:
+		use, selected = "webhooks", "webhook URLs"
+		example = heredoc.Doc(`
+			xibugo copy webhooks --from-profile sandbox --to-profile prod \
+			  --rewrite-url https://sandbox.example.com=https://api.example.com
+		`)
+	case manifest.KindSubscription:
+		use, selected = "subscriptions", "subscribed event types"
+		example = heredoc.Doc(`
+			xibugo copy subscriptions --from-profile sandbox --to-profile prod \
+			  --rewrite-url https://sandbox.example.com=https://api.example.com
+		`)
+	}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   fmt.Sprintf("Copy %s between profiles", strings.ReplaceAll(use, "-", " ")),
+		Args:    cobra.NoArgs,
+		Example: example,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			from, to := viper.GetString(flagFromProfile), viper.GetString(flagToProfile)
+			if from == to {
+				return &UsageError{Err: errors.New("source and target profiles must differ")}
+			}
+
+			selector, err := compileSelector(viper.GetString(flagSelector))
+			if err != nil {
+				return err
+			}
+
+			rules, err := parseRewriteRules(viper.GetStringSlice(flagRewriteURL))
+			if err != nil {
+				return err
+			}
+
+			fromCfg, err := loadProfile(from)
+			if err != nil {
+				return err
+			}
+
+			toCfg, err := loadProfile(to)
+			if err != nil {
+				return err
+			}
+
+			source, err := newClient(fromCfg, opts)
+			if err != nil {
+				return err
+			}
+
+			target, err := newClient(toCfg, opts)
+			if err != nil {
+				return err
+			}
+
+			sourceState, err := manifest.Fetch(cmd.Context(), source)
+			if err != nil {
+				return fmt.Errorf("reading profile %q: %w", from, err)
+			}
+
+			targetState, err := manifest.Fetch(cmd.Context(), target)
+			if err != nil {
+				return fmt.Errorf("reading profile %q: %w", to, err)
+			}
+
+			secrets := manifest.SecretsOmit
+			if viper.GetBool(flagCopySecrets) {
+				secrets = manifest.SecretsInclude
+			}
+
+			all, err := manifest.FromState(sourceState, secrets)
+			if err != nil {
+				return err
+			}
+
+			m := selectResources(all, kind, selector, rules)
+
+			// Subscriptions are copied onto webhooks that already exist in the
+			// target, which gives them the target webhook IDs.
+			existing := map[string]bool{}
+			for _, wh := range targetState.Webhooks {
+				existing[wh.URL] = true
+			}
+
+			subscriptions := m.Subscriptions[:0]
+			for _, sub := range m.Subscriptions {
+				if !existing[sub.Webhook] {
+					cmd.PrintErrf("Skipping subscription %s: no such webhook in profile %q, copy webhooks first\n", sub, to)
+					continue
+				}
+
+				subscriptions = append(subscriptions, sub)
+			}
+
+			m.Subscriptions = subscriptions
+
+			plan, err := manifest.Compute(m, targetState, false)
+			if err != nil {
+				return err
+			}
+
+			if plan.Empty() {
+				if ok, err := printStructured(cmd.OutOrStdout(), plan); ok {
+					return err
+				}
+
+				cmd.Printf("Nothing to copy, profile %q already has every selected resource.\n", to)
+
+				return nil
+			}
+
+			if viper.GetBool(flagDryRun) {
+				if ok, err := printStructured(cmd.OutOrStdout(), plan); ok {
+					return err
+				}
+
+				printPlan(cmd.OutOrStdout(), plan)
+
+				return nil
+			}
+
+			printPlan(cmd.ErrOrStderr(), plan)
+
+			if err := checkBreakingSchemas(cmd, plan); err != nil {
+				return err
+			}
+
+			if !viper.GetBool(flagYes) {
+				if !isInteractive(cmd) {
+					return &UsageError{Err: errors.New("confirmation required, pass --yes to copy without prompting")}
+				}
+
+				ok, err := promptConfirmation(fmt.Sprintf("Copy into profile %q?", to), false)
+				if err != nil {
+					return err
+				}
+
+				if !ok {
+					return errors.New("did not confirm")
+				}
+			}
+
+			structured := outputFormat() == formatJSON || outputFormat() == formatYAML
+
+			err = plan.Apply(cmd.Context(), target, func(c manifest.Change) {
+				if c.Kind == manifest.KindEventType {
+					forgetSchema(toCfg, c.Name)
+				}
+
+				if !structured {
+					cmd.Printf("%s %s\n", pastTense(c.Action), c)
+				}
+			})
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), plan); ok {
+				return err
+			}
+
+			cmd.Printf(
+				"Copied: %d created, %d updated.\n",
+				plan.Count(manifest.ActionCreate),
+				plan.Count(manifest.ActionUpdate),
+			)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagFromProfile, "", "Profile to copy from")
+	cmd.Flags().String(flagToProfile, "", "Profile to copy to")
+	cmd.Flags().String(flagSelector, "", fmt.Sprintf("Only copy %s matching this pattern, where * matches anything", selected))
+	cmd.Flags().Bool(flagDryRun, false, "Show what would be copied without changing anything")
+	cmd.Flags().BoolP(flagYes, "y", false, "Do not ask for confirmation")
+	_ = cmd.MarkFlagRequired(flagFromProfile)
+	_ = cmd.MarkFlagRequired(flagToProfile)
+
+	if kind == manifest.KindEventType {
+		cmd.Flags().Bool(flagForce, false, "Copy breaking schema changes")
+	}
+
+	if kind != manifest.KindEventType {
+		cmd.Flags().StringSlice(flagRewriteURL, nil, "Rewrite webhook URLs starting with FROM to start with TO, given as FROM=TO")
+	}
+
+	if kind == manifest.KindWebhook {
+		cmd.Flags().Bool(flagCopySecrets, false, "Copy webhook secrets instead of letting the target generate new ones")
+	}
+
+	return cmd
+}
+
+// selectResources keeps the resources of one kind matching selector, with
+// webhook URLs rewritten.
+func selectResources(m *manifest.Manifest, kind manifest.Kind, selector *regexp.Regexp, rules []rewriteRule) *manifest.Manifest {
+	selected := &manifest.Manifest{}
+
+	switch kind {
+	case manifest.KindEventType:
+		for _, et := range m.EventTypes {
+			if selector.MatchString(et.Name) {
+				selected.EventTypes = append(selected.EventTypes, et)
+			}
+		}
+	case manifest.KindWebhook:
+		for _, wh := range m.Webhooks {
+			if selector.MatchString(wh.URL) {
+				wh.URL = rewriteURL(wh.URL, rules)
+				selected.Webhooks = append(selected.Webhooks, wh)
+			}
+		}
+	case manifest.KindSubscription:
+		for _, sub := range m.Subscriptions {
+			if selector.MatchString(sub.EventType) {
+				sub.Webhook = rewriteURL(sub.Webhook, rules)
+				selected.Subscriptions = append(selected.Subscriptions, sub)
+			}
+		}
+	}
+
+	return selected
+}
+
+// compileSelector turns a pattern where * matches any sequence of characters
+// into a regular expression. An empty pattern matches everything.
+func compileSelector(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		pattern = "*"
+	}
+
+	expr := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, ".*")
+
+	re, err := regexp.Compile("^" + expr + "$")
+	if err != nil {
+		return nil, &UsageError{Err: fmt.Errorf("invalid selector %q: %w", pattern, err)}
+	}
+
+	return re, nil
+}
+
+type rewriteRule struct {
+	from, to string
+}
+
+func parseRewriteRules(values []string) ([]rewriteRule, error) {
+	rules := make([]rewriteRule, 0, len(values))
+
+	for _, v := range values {
+		from, to, ok := strings.Cut(v, "=")
+		if !ok || from == "" {
+			return nil, &UsageError{Err: fmt.Errorf("invalid URL rewrite %q, expected FROM=TO", v)}
+		}
+
+		rules = append(rules, rewriteRule{from: from, to: to})
+	}
+
+	return rules, nil
+}
+
+// rewriteURL applies the first rule whose prefix matches u.
+func rewriteURL(u string, rules []rewriteRule) string {
+	for _, r := range rules {
+		if strings.HasPrefix(u, r.from) {
+			return r.to + strings.TrimPrefix(u, r.from)
+		}
+	}
+
+	return u
+}
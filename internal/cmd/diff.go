@@ -0,0 +1,136 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/manifest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagIgnoreExtra = "ignore-extra"
+
+// driftReport is the structured output of diff. Missing resources are in the
+// manifest only, changed ones differ and extra ones are in the account only.
+type driftReport struct {
+	Drift   bool              `json:"drift"`
+	Missing int               `json:"missing"`
+	Changed int               `json:"changed"`
+	Extra   int               `json:"extra"`
+	Changes []manifest.Change `json:"changes"`
+}
+
+func NewCmdDiff(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare a manifest with the account and report drift",
+		Long: heredoc.Doc(`
+			Compare a manifest with the event types, webhooks and subscriptions of
+			the account without changing anything.
+
+			Exits with code 10 when they differ, so a scheduled job can alert when
+			resources are edited outside of the manifest. Resources in the account
+			but not in the manifest count as drift unless --ignore-extra is set.
+		`),
+		Args: cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo diff -f xigubo.yaml
+			xibugo diff -f xigubo.yaml --ignore-extra -o json
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			m, err := readManifest(cmd, viper.GetString(flagFile))
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			state, err := manifest.Fetch(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+
+			plan, err := manifest.Compute(m, state, !viper.GetBool(flagIgnoreExtra))
+			if err != nil {
+				return err
+			}
+
+			report := driftReport{
+				Drift:   !plan.Empty(),
+				Missing: plan.Count(manifest.ActionCreate),
+				Changed: plan.Count(manifest.ActionUpdate),
+				Extra:   plan.Count(manifest.ActionDelete),
+				Changes: plan.Changes,
+			}
+
+			ok, err := printStructured(cmd.OutOrStdout(), report)
+			if err != nil {
+				return err
+			}
+
+			if !ok {
+				printDrift(cmd, plan, report)
+			}
+
+			if report.Drift {
+				return &DriftError{Changes: len(plan.Changes)}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().StringP(flagFile, "f", "", "Manifest file, or - to read standard input")
+	cmd.Flags().Bool(flagIgnoreExtra, false, "Do not report resources that are only in the account")
+	_ = cmd.MarkFlagRequired(flagFile)
+
+	return cmd
+}
+
+func printDrift(cmd *cobra.Command, plan *manifest.Plan, report driftReport) {
+	if !report.Drift {
+		cmd.Println("No drift, the account matches the manifest.")
+		return
+	}
+
+	printChanges(cmd.OutOrStdout(), plan)
+
+	cmd.Printf(
+		"\nDrift: %d missing from the account, %d changed, %d not in the manifest.\n",
+		report.Missing,
+		report.Changed,
+		report.Extra,
+	)
+}
@@ -0,0 +1,106 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+)
+
+// errorOutput is the shape of an error printed with --output json.
+type errorOutput struct {
+	Error errorObject `json:"error"`
+}
+
+type errorObject struct {
+	Message   string           `json:"message"`
+	Status    int              `json:"status,omitempty"`
+	Code      string           `json:"code,omitempty"`
+	Details   []api.FieldError `json:"details,omitempty"`
+	RequestID string           `json:"request_id,omitempty"`
+	ExitCode  int              `json:"exit_code"`
+}
+
+// PrintError writes err to w, as a JSON object when --output json is set
+// and in human readable form otherwise.
+func PrintError(w io.Writer, err error) {
+	obj := errorObject{
+		Message:  err.Error(),
+		ExitCode: ExitCode(err),
+	}
+
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		obj.Message = apiErr.Message
+		if obj.Message == "" {
+			obj.Message = http.StatusText(apiErr.StatusCode)
+		}
+
+		obj.Status = apiErr.StatusCode
+		obj.Code = apiErr.Code
+		obj.Details = apiErr.Details
+		obj.RequestID = apiErr.RequestID
+	}
+
+	var schemaErr *schema.ValidationError
+	if errors.As(err, &schemaErr) {
+		for _, e := range schemaErr.Errors {
+			obj.Details = append(obj.Details, api.FieldError{Field: e.Pointer, Message: e.Message})
+		}
+	}
+
+	if outputFormat() == formatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		_ = enc.Encode(errorOutput{Error: obj})
+
+		return
+	}
+
+	fmt.Fprintf(w, "Error: %s\n", obj.Message)
+
+	if obj.Status != 0 {
+		fmt.Fprintf(w, "  Status:     %d %s\n", obj.Status, http.StatusText(obj.Status))
+	}
+
+	if obj.Code != "" {
+		fmt.Fprintf(w, "  Code:       %s\n", obj.Code)
+	}
+
+	if obj.RequestID != "" {
+		fmt.Fprintf(w, "  Request ID: %s\n", obj.RequestID)
+	}
+
+	for _, detail := range obj.Details {
+		if detail.Field != "" {
+			fmt.Fprintf(w, "  - %s: %s\n", detail.Field, detail.Message)
+		} else {
+			fmt.Fprintf(w, "  - %s\n", detail.Message)
+		}
+	}
+
+	var usageErr *UsageError
+	if errors.As(err, &usageErr) || isUnknownCommand(err) {
+		fmt.Fprintln(w, "Run 'xibugo --help' for usage.")
+	}
+}
@@ -17,13 +17,34 @@
 package cmd
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
 	"github.com/getumbeluzi/xibugo-cli/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+const (
+	flagStatus    = "status"
+	flagWebhook   = "webhook"
+	flagPage      = "page"
+	flagPerPage   = "per-page"
+	flagSince     = "since"
+	flagInterval  = "interval"
+	flagDeliverAt = "deliver-at"
+	flagDelay     = "delay"
+)
+
 func NewCmdEvent(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "event",
@@ -37,6 +58,10 @@ func NewCmdEvent(opts *internal.CommandOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdEventCancel(opts))
 	cmd.AddCommand(NewCmdEventResend(opts))
 	cmd.AddCommand(NewCmdEventGet(opts))
+	cmd.AddCommand(NewCmdEventTail(opts))
+	cmd.AddCommand(NewCmdEventAttempts(opts))
+	cmd.AddCommand(NewCmdEventValidate(opts))
+	cmd.AddCommand(NewCmdEventScheduled(opts))
 
 	return cmd
 }
@@ -45,8 +70,10 @@ func NewCmdEventList(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List events",
+		Args:  cobra.NoArgs,
 		Example: heredoc.Doc(`
 			xibugo event list
+			xibugo event list --type 'order.*' --status failed
 		`),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
@@ -61,12 +88,47 @@ func NewCmdEventList(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			events, _, err := client.ListEvents(cmd.Context(), api.EventListOptions{
+				ListOptions: api.ListOptions{
+					Page:    viper.GetInt(flagPage),
+					PerPage: viper.GetInt(flagPerPage),
+				},
+				Type:      viper.GetString(flagType),
+				Status:    viper.GetString(flagStatus),
+				WebhookID: viper.GetString(flagWebhook),
+			})
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), events); ok {
+				return err
+			}
+
+			color := colorEnabled(cmd.OutOrStdout())
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTYPE\tSTATUS\tCREATED")
+
+			for _, ev := range events {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ev.ID, ev.Type, colorStatus(ev.Status, color), ev.CreatedAt.Local().Format(time.RFC3339))
+			}
+
+			return w.Flush()
 		},
 	}
 
+	cmd.Flags().String(flagType, "", "Only list events of this type, may be a pattern such as 'order.*'")
+	cmd.Flags().String(flagStatus, "", "Only list events with this status")
+	cmd.Flags().String(flagWebhook, "", "Only list events delivered to this webhook")
+	cmd.Flags().Int(flagPage, 1, "Page to list")
+	cmd.Flags().Int(flagPerPage, api.DefaultPerPage, "Number of events per page")
+
 	return cmd
 }
 
@@ -103,11 +165,39 @@ func NewCmdEventDelete(opts *internal.CommandOptions) *cobra.Command {
 func NewCmdEventCreate(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "create",
-		Short: "Create an event type",
+		Short: "Publish an event",
+		Long: heredoc.Doc(`
+			Publish an event. When its type has a JSON Schema the payload is
+			validated locally first and the event is not sent if it does not
+			match, unless --no-validate is given.
+
+			With --example the data is generated from the schema instead, which is
+			handy for smoke testing a sandbox.
+
+			With --batch the events are read from an NDJSON file, or standard
+			input, one {"type", "data", "idempotency_key", "deliver_at"} object
+			per line, and published in batches. Events with an idempotency key
+			are never published twice. The outcome of every line can be written
+			to a results file with --results; rerunning with --resume skips the
+			lines it records as published.
+
+			With --deliver-at or --delay the event is scheduled and only
+			delivered from then on, as are the batch lines without a
+			"deliver_at" of their own. Scheduled events are listed by
+			"xibugo event scheduled list" and can be cancelled until they are
+			delivered.
+		`),
 		Example: heredoc.Doc(`
-			xibugo event create --webhook 123
+			xibugo event create --type order.created --data '{"id":"ord_1"}'
+			xibugo event create --type order.created --data @order.json
+			xibugo event create --type order.created --example
+			xibugo event create --type reminder.due --data @reminder.json --deliver-at 2026-11-01T09:00:00Z
+			xibugo event create --type reminder.due --data @reminder.json --delay 15m
+			xibugo event create --batch events.ndjson --results results.ndjson
+			xibugo event create --batch events.ndjson --results results.ndjson --resume
+			cat events.ndjson | xibugo event create --batch - --concurrency 8 --rate 50
 		`),
-		Args: cobra.MaximumNArgs(1),
+		Args: cobra.NoArgs,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
 				panic(err)
@@ -116,27 +206,112 @@ func NewCmdEventCreate(opts *internal.CommandOptions) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			internal.SetupIO(cmd, opts)
 
+			batch := cmd.Flags().Changed(flagBatch)
+			example := viper.GetBool(flagExample)
+
+			if !batch {
+				if viper.GetString(flagType) == "" {
+					return &UsageError{Err: errors.New("--type is required")}
+				}
+
+				if !example && !cmd.Flags().Changed(flagData) {
+					return &UsageError{Err: errors.New("either --data, --example or --batch is required")}
+				}
+			}
+
+			deliverAt, err := deliveryTime(cmd)
+			if err != nil {
+				return err
+			}
+
 			cfg, err := config.New()
 			if err != nil {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if batch {
+				ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+				defer stop()
+
+				return publishBatch(ctx, cmd, cfg, client, deliverAt)
+			}
+
+			eventType := viper.GetString(flagType)
+
+			var data json.RawMessage
+			if example {
+				data, err = exampleData(cmd.Context(), cfg, client, eventType)
+			} else {
+				data, err = readPayload(cmd)
+			}
+
+			if err != nil {
+				return err
+			}
+
+			if !viper.GetBool(flagNoValidate) {
+				if err := validatePayload(cmd.Context(), cfg, client, eventType, data); err != nil {
+					return err
+				}
+			}
+
+			event, err := client.CreateEvent(cmd.Context(), api.EventParams{
+				Type:      eventType,
+				Data:      data,
+				DeliverAt: deliverAt,
+			})
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), event); ok {
+				return err
+			}
+
+			if event.DeliverAt != nil {
+				cmd.Printf("Scheduled event %s for %s\n", event.ID, event.DeliverAt.Local().Format(time.RFC3339))
+				return nil
+			}
+
+			cmd.Printf("Created event %s\n", event.ID)
 
 			return nil
 		},
 	}
 
+	cmd.Flags().String(flagType, "", "Type of the event, or of the batch lines that do not set one")
+	cmd.Flags().String(flagData, "", "Event data as JSON, inline, as @file or @- for standard input")
+	cmd.Flags().Bool(flagExample, false, "Publish data generated from the schema of the event type")
+	cmd.Flags().Bool(flagNoValidate, false, "Do not validate the data against the schema of the event type")
+	cmd.Flags().String(flagBatch, "", "NDJSON file with the events to publish, - for standard input")
+	cmd.Flags().Int(flagBatchSize, defaultBatchSize, "Number of events sent per request with --batch")
+	cmd.Flags().Int(flagConcurrency, defaultConcurrency, "Number of requests sent in parallel with --batch")
+	cmd.Flags().Float64(flagRate, defaultRate, "Maximum number of requests per second with --batch, 0 for no limit")
+	cmd.Flags().String(flagResults, "", "NDJSON file the outcome of every batch line is written to")
+	cmd.Flags().Bool(flagResume, false, "Skip the batch lines the results file records as published")
+	cmd.Flags().String(flagDeliverAt, "", "Deliver the event at this RFC 3339 time instead of now")
+	cmd.Flags().Duration(flagDelay, 0, "Deliver the event after this delay instead of now, such as 15m")
+	cmd.MarkFlagsMutuallyExclusive(flagData, flagExample)
+	cmd.MarkFlagsMutuallyExclusive(flagBatch, flagData)
+	cmd.MarkFlagsMutuallyExclusive(flagBatch, flagExample)
+	cmd.MarkFlagsMutuallyExclusive(flagDeliverAt, flagDelay)
+
 	return cmd
 }
 
 func NewCmdEventGet(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "get",
-		Short: "Retrieve an event type",
+		Use:   "get <event-id>",
+		Short: "Retrieve an event",
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
 			xibugo event get 123
-			xibugo event get 123
+			xibugo event get 123 -o json
 		`),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
@@ -151,7 +326,31 @@ func NewCmdEventGet(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			event, err := client.GetEvent(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), event); ok {
+				return err
+			}
+
+			cmd.Printf("ID:       %s\n", event.ID)
+			cmd.Printf("Type:     %s\n", event.Type)
+			cmd.Printf("Status:   %s\n", colorStatus(event.Status, colorEnabled(cmd.OutOrStdout())))
+			cmd.Printf("Created:  %s\n", event.CreatedAt.Local().Format(time.RFC3339))
+			cmd.Printf("Updated:  %s\n", event.UpdatedAt.Local().Format(time.RFC3339))
+
+			if event.DeliverAt != nil {
+				cmd.Printf("Deliver:  %s\n", event.DeliverAt.Local().Format(time.RFC3339))
+			}
+
+			cmd.Printf("Data:     %s\n", event.Data)
 
 			return nil
 		},
@@ -160,14 +359,15 @@ func NewCmdEventGet(opts *internal.CommandOptions) *cobra.Command {
 	return cmd
 }
 
-func NewCmdEventCancel(opts *internal.CommandOptions) *cobra.Command {
+func NewCmdEventAttempts(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "cancel",
-		Short: "Cancel an event",
+		Use:   "attempts <event-id>",
+		Short: "List the delivery attempts of an event",
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
-			xibugo event cancel 123
+			xibugo event attempts 123
+			xibugo event attempts 123 --failed-only
 		`),
-		Args: cobra.MaximumNArgs(1),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
 				panic(err)
@@ -181,23 +381,37 @@ func NewCmdEventCancel(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			list := func(ctx context.Context, listOpts api.AttemptListOptions) ([]api.Attempt, *api.Pagination, error) {
+				return client.ListEventAttempts(ctx, args[0], listOpts)
+			}
+
+			attempts, err := collectAttempts(cmd.Context(), list, attemptListOptions(viper.GetBool(flagFailedOnly)))
+			if err != nil {
+				return err
+			}
+
+			return printAttempts(cmd, attempts, true)
 		},
 	}
 
+	cmd.Flags().Bool(flagFailedOnly, false, "Only list failed attempts")
+
 	return cmd
 }
 
-func NewCmdEventResend(opts *internal.CommandOptions) *cobra.Command {
+func NewCmdEventCancel(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "resend",
-		Short: "Resend an event",
+		Use:   "cancel <event-id>",
+		Short: "Cancel a pending or scheduled event",
 		Example: heredoc.Doc(`
-			xibugo event resend 123
+			xibugo event cancel 123
 		`),
-		Args: cobra.MaximumNArgs(1),
+		Args: cobra.ExactArgs(1),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
 				panic(err)
@@ -211,7 +425,21 @@ func NewCmdEventResend(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			event, err := client.CancelEvent(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), event); ok {
+				return err
+			}
+
+			cmd.Printf("Cancelled event %s\n", event.ID)
 
 			return nil
 		},
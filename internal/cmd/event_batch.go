@@ -0,0 +1,480 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/progress"
+	"github.com/getumbeluzi/xibugo-cli/internal/ratelimit"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagBatch     = "batch"
+	flagBatchSize = "batch-size"
+	flagResults   = "results"
+	flagResume    = "resume"
+
+	defaultBatchSize = 20
+	// maxBatchLineSize bounds a line of a batch file, which holds one event.
+	maxBatchLineSize = 5 << 20
+
+	batchStatusPublished = "published"
+	batchStatusFailed    = "failed"
+)
+
+// batchLine is an event read from a line of a batch file, numbered from 1.
+type batchLine struct {
+	Line   int
+	Params api.EventParams
+}
+
+// batchResult is the outcome of publishing a line of a batch file, as
+// written to the results file.
+type batchResult struct {
+	Line           int    `json:"line"`
+	Status         string `json:"status"`
+	EventID        string `json:"event_id,omitempty"`
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
+	Error          string `json:"error,omitempty"`
+}
+
+type batchSummary struct {
+	Lines     int `json:"lines"`
+	Skipped   int `json:"skipped"`
+	Published int `json:"published"`
+	Failed    int `json:"failed"`
+}
+
+// publishBatch publishes the events of an NDJSON file, one per line, as
+// given to "event create --batch". The file is read as the events are sent,
+// so it need not fit in memory.
+func publishBatch(ctx context.Context, cmd *cobra.Command, cfg *config.Config, client *api.Client, deliverAt *time.Time) error {
+	batchSize := viper.GetInt(flagBatchSize)
+	if batchSize < 1 || batchSize > api.MaxEventBatchSize {
+		return &UsageError{Err: fmt.Errorf("--batch-size must be between 1 and %d", api.MaxEventBatchSize)}
+	}
+
+	resume := viper.GetBool(flagResume)
+	if resume && viper.GetString(flagResults) == "" {
+		return &UsageError{Err: errors.New("--resume requires --results")}
+	}
+
+	input, err := openInput(viper.GetString(flagBatch), cmd.InOrStdin())
+	if err != nil {
+		return err
+	}
+
+	defer input.Close()
+
+	results, err := openBatchResults(viper.GetString(flagResults), resume)
+	if err != nil {
+		return err
+	}
+
+	defer results.Close()
+
+	run := &batchRun{
+		cfg:      cfg,
+		client:   client,
+		results:  results,
+		bar:      progress.New(cmd.ErrOrStderr(), 0, colorEnabled(cmd.ErrOrStderr())),
+		defaults: api.EventParams{Type: viper.GetString(flagType), DeliverAt: deliverAt},
+		validate: !viper.GetBool(flagNoValidate),
+		schemas:  map[string]compiledSchema{},
+	}
+
+	err = run.publish(ctx, input, batchSize, viper.GetInt(flagConcurrency), ratelimit.New(viper.GetFloat64(flagRate)))
+	run.bar.Finish()
+
+	if closeErr := results.Close(); closeErr != nil && err == nil {
+		err = fmt.Errorf("writing results: %w", closeErr)
+	}
+
+	if ok, err := printStructured(cmd.OutOrStdout(), run.summary); ok {
+		if err != nil {
+			return err
+		}
+	} else {
+		cmd.Printf(
+			"Published %s, %d failed, %d skipped\n",
+			plural(run.summary.Published, "event"), run.summary.Failed, run.summary.Skipped,
+		)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return errors.New("interrupted")
+	}
+
+	if run.summary.Failed > 0 {
+		return fmt.Errorf("%s could not be published", plural(run.summary.Failed, "event"))
+	}
+
+	return nil
+}
+
+type compiledSchema struct {
+	schema *schema.Schema
+	err    error
+}
+
+// batchRun tracks the outcome of publishing a batch file.
+type batchRun struct {
+	cfg      *config.Config
+	client   *api.Client
+	results  *batchResults
+	bar      *progress.Bar
+	defaults api.EventParams
+	validate bool
+
+	// schemas caches the schema of each event type. It is only used by
+	// the goroutine reading the file.
+	schemas map[string]compiledSchema
+
+	mu      sync.Mutex
+	summary batchSummary
+}
+
+// publish reads the batch file line by line and sends its events in batches
+// of size, with at most concurrency requests in flight. Lines that are not
+// valid events are recorded as failures without being sent.
+func (r *batchRun) publish(ctx context.Context, input io.Reader, size, concurrency int, limiter *ratelimit.Limiter) error {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var wg sync.WaitGroup
+
+	batches := make(chan []batchLine)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for batch := range batches {
+				r.send(ctx, batch)
+			}
+		}()
+	}
+
+	defer func() {
+		close(batches)
+		wg.Wait()
+	}()
+
+	batch := make([]batchLine, 0, size)
+
+	flush := func() bool {
+		if limiter.Wait(ctx) != nil {
+			return false
+		}
+
+		batches <- batch
+		batch = make([]batchLine, 0, size)
+
+		return true
+	}
+
+	scanner := bufio.NewScanner(input)
+	scanner.Buffer(nil, maxBatchLineSize)
+
+	for n := 1; scanner.Scan(); n++ {
+		line, ok := r.parse(n, scanner.Bytes())
+		if !ok || (r.validate && !r.check(ctx, line)) {
+			continue
+		}
+
+		batch = append(batch, line)
+
+		if len(batch) == size && !flush() {
+			return nil
+		}
+	}
+
+	if len(batch) > 0 && !flush() {
+		return nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("reading batch: %w", err)
+	}
+
+	return nil
+}
+
+// parse reads the event on line n of a batch file, reporting false for blank
+// lines, lines already published according to the results file and lines
+// that are not valid events, which are recorded as failures. The type and
+// delivery time of the defaults apply to lines that do not set them.
+func (r *batchRun) parse(n int, text []byte) (batchLine, bool) {
+	text = bytes.TrimSpace(text)
+	if len(text) == 0 {
+		return batchLine{}, false
+	}
+
+	r.mu.Lock()
+	r.summary.Lines++
+	skip := r.results.Published(n)
+	if skip {
+		r.summary.Skipped++
+	}
+	r.mu.Unlock()
+
+	if skip {
+		return batchLine{}, false
+	}
+
+	var params api.EventParams
+	if err := json.Unmarshal(text, &params); err != nil {
+		r.record(batchResult{Line: n, Status: batchStatusFailed, Error: "invalid JSON: " + err.Error()})
+		return batchLine{}, false
+	}
+
+	if params.Type == "" {
+		params.Type = r.defaults.Type
+	}
+
+	if params.DeliverAt == nil {
+		params.DeliverAt = r.defaults.DeliverAt
+	}
+
+	res := batchResult{Line: n, Status: batchStatusFailed, IdempotencyKey: params.IdempotencyKey}
+
+	switch {
+	case params.Type == "":
+		res.Error = "type is required, set it on the line or pass --type"
+	case len(params.Data) == 0:
+		res.Error = "data is required"
+	default:
+		return batchLine{Line: n, Params: params}, true
+	}
+
+	r.record(res)
+
+	return batchLine{}, false
+}
+
+// check validates an event against the schema of its type, recording it as
+// a failure when it does not match.
+func (r *batchRun) check(ctx context.Context, l batchLine) bool {
+	c, ok := r.schemas[l.Params.Type]
+	if !ok {
+		c.schema, c.err = eventSchema(ctx, r.cfg, r.client, l.Params.Type)
+		r.schemas[l.Params.Type] = c
+	}
+
+	err := c.err
+	if err == nil && c.schema != nil {
+		err = c.schema.Validate(l.Params.Data)
+	}
+
+	if err == nil {
+		return true
+	}
+
+	r.record(batchResult{
+		Line:           l.Line,
+		Status:         batchStatusFailed,
+		IdempotencyKey: l.Params.IdempotencyKey,
+		Error:          batchError(err),
+	})
+
+	return false
+}
+
+func (r *batchRun) send(ctx context.Context, batch []batchLine) {
+	params := make([]api.EventParams, len(batch))
+	for i, l := range batch {
+		params[i] = l.Params
+	}
+
+	created, err := r.client.CreateEvents(ctx, params)
+
+	for i, l := range batch {
+		res := batchResult{Line: l.Line, Status: batchStatusFailed, IdempotencyKey: l.Params.IdempotencyKey}
+
+		switch {
+		case err != nil:
+			res.Error = batchError(err)
+		case created[i].Error != nil:
+			res.Error = batchError(created[i].Error)
+		default:
+			res.Status = batchStatusPublished
+			res.EventID = created[i].Event.ID
+		}
+
+		r.record(res)
+	}
+}
+
+// record counts the outcome of a line and writes it to the results file.
+func (r *batchRun) record(res batchResult) {
+	r.mu.Lock()
+	if res.Status == batchStatusPublished {
+		r.summary.Published++
+	} else {
+		r.summary.Failed++
+	}
+	r.mu.Unlock()
+
+	r.results.Write(res)
+
+	if res.Error != "" {
+		r.bar.Printf("line %d: %s\n", res.Line, res.Error)
+	}
+
+	r.bar.Add(res.Status == batchStatusPublished)
+}
+
+// batchError describes why a line was not published, with the details of
+// validation errors.
+func batchError(err error) string {
+	var (
+		schemaErr *schema.ValidationError
+		apiErr    *api.Error
+		details   []string
+	)
+
+	switch {
+	case errors.As(err, &schemaErr):
+		for _, e := range schemaErr.Errors {
+			details = append(details, e.String())
+		}
+	case errors.As(err, &apiErr):
+		for _, d := range apiErr.Details {
+			details = append(details, d.Field+": "+d.Message)
+		}
+	}
+
+	if len(details) == 0 {
+		return err.Error()
+	}
+
+	return err.Error() + " (" + strings.Join(details, "; ") + ")"
+}
+
+// batchResults is the NDJSON file the outcome of every line is written to.
+// When resuming, the lines it records as published are skipped. A nil
+// batchResults records nothing.
+type batchResults struct {
+	mu        sync.Mutex
+	f         *os.File
+	enc       *json.Encoder
+	published map[int]bool
+	err       error
+}
+
+func openBatchResults(path string, resume bool) (*batchResults, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if resume {
+		flags = os.O_RDWR | os.O_CREATE | os.O_APPEND
+	}
+
+	f, err := os.OpenFile(path, flags, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &batchResults{f: f, enc: json.NewEncoder(f), published: map[int]bool{}}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxBatchLineSize)
+
+	for scanner.Scan() {
+		var res batchResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			continue
+		}
+
+		r.published[res.Line] = res.Status == batchStatusPublished
+	}
+
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading results: %w", err)
+	}
+
+	return r, nil
+}
+
+func (r *batchResults) Published(line int) bool {
+	if r == nil {
+		return false
+	}
+
+	return r.published[line]
+}
+
+// Write appends a result. The first error is kept and returned by Close.
+func (r *batchResults) Write(res batchResult) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.enc.Encode(res); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Close closes the file, returning the first error met writing to it. It
+// may be called more than once.
+func (r *batchResults) Close() error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.f != nil {
+		if err := r.f.Close(); err != nil && r.err == nil {
+			r.err = err
+		}
+
+		r.f = nil
+	}
+
+	return r.err
+}
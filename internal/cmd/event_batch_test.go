@@ -0,0 +1,128 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/progress"
+	"github.com/getumbeluzi/xibugo-cli/internal/ratelimit"
+)
+
+func TestBatchResume(t *testing.T) {
+	client, cfg := newMockAPI(t)
+
+	input := strings.Join([]string{
+		`{"type":"order.created","data":{"n":1},"idempotency_key":"k1"}`,
+		`{"type":"order.created","data":{"n":2},"idempotency_key":"k2"}`,
+		``,
+		`not json`,
+		`{"data":{"n":5}}`,
+		`{"type":"order.created","data":{"n":6},"idempotency_key":"k6"}`,
+	}, "\n")
+
+	// A previous run published lines 1 and 6, and failed on line 2.
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	previous := strings.Join([]string{
+		`{"line":1,"status":"published","event_id":"evt_1"}`,
+		`{"line":2,"status":"failed","error":"server error"}`,
+		`{"line":6,"status":"published","event_id":"evt_6"}`,
+	}, "\n") + "\n"
+
+	if err := os.WriteFile(path, []byte(previous), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	results, err := openBatchResults(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run := &batchRun{
+		cfg:     cfg,
+		client:  client,
+		results: results,
+		bar:     progress.New(io.Discard, 0, false),
+	}
+
+	if err := run.publish(context.Background(), strings.NewReader(input), 2, 2, ratelimit.New(0)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := results.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := batchSummary{Lines: 5, Skipped: 2, Published: 1, Failed: 2}
+	if run.summary != want {
+		t.Errorf("got summary %+v, want %+v", run.summary, want)
+	}
+
+	events, _, err := client.ListEvents(context.Background(), api.EventListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 1 || string(events[0].Data) != `{"n":2}` {
+		t.Errorf("published %d events, want only that of line 2", len(events))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	// The results of this run are appended to those of the previous one.
+	statuses := map[int][]string{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var res batchResult
+		if err := json.Unmarshal(scanner.Bytes(), &res); err != nil {
+			t.Fatal(err)
+		}
+
+		statuses[res.Line] = append(statuses[res.Line], res.Status)
+	}
+
+	wantStatuses := map[int][]string{
+		1: {batchStatusPublished},
+		2: {batchStatusFailed, batchStatusPublished},
+		4: {batchStatusFailed},
+		5: {batchStatusFailed},
+		6: {batchStatusPublished},
+	}
+
+	if len(statuses) != len(wantStatuses) {
+		t.Fatalf("got results %v, want %v", statuses, wantStatuses)
+	}
+
+	for line, want := range wantStatuses {
+		if !equalStrings(statuses[line], want) {
+			t.Errorf("line %d has results %v, want %v", line, statuses[line], want)
+		}
+	}
+}
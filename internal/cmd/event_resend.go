@@ -0,0 +1,491 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/progress"
+	"github.com/getumbeluzi/xibugo-cli/internal/ratelimit"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagFailed      = "failed"
+	flagYes         = "yes"
+	flagConcurrency = "concurrency"
+	flagRate        = "rate"
+	flagCheckpoint  = "checkpoint"
+
+	defaultConcurrency = 4
+	defaultRate        = 10
+	resendSampleSize   = 5
+)
+
+func NewCmdEventResend(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "resend [<event-id>]",
+		Short: "Resend an event, or every failed event matching a filter",
+		Example: heredoc.Doc(`
+			xibugo event resend 123
+			xibugo event resend 123 --webhook 456
+			xibugo event resend --failed --webhook 123 --since 2h --type 'order.*'
+			xibugo event resend --failed --since 24h --checkpoint resend.log --yes
+		`),
+		Args: cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			failed := viper.GetBool(flagFailed)
+			if (len(args) == 1) == failed {
+				return &UsageError{Err: errors.New("pass either an event id or --failed")}
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if len(args) == 1 {
+				var event *api.Event
+				if webhookID := viper.GetString(flagWebhook); webhookID != "" {
+					event, err = client.ResendEventToWebhook(cmd.Context(), args[0], webhookID)
+				} else {
+					event, err = client.ResendEvent(cmd.Context(), args[0])
+				}
+
+				if err != nil {
+					return err
+				}
+
+				if ok, err := printStructured(cmd.OutOrStdout(), event); ok {
+					return err
+				}
+
+				cmd.Printf("Resent event %s\n", event.ID)
+
+				return nil
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			return resendFailed(ctx, cmd, client)
+		},
+	}
+
+	cmd.Flags().Bool(flagFailed, false, "Resend every failed event matching the filters")
+	cmd.Flags().String(flagType, "", "Only resend events of this type, may be a pattern such as 'order.*'")
+	cmd.Flags().String(flagWebhook, "", "Only resend to this webhook, and with --failed only events that failed to reach it")
+	cmd.Flags().Duration(flagSince, 0, "Only resend events created within this period")
+	cmd.Flags().BoolP(flagYes, "y", false, "Do not ask for confirmation")
+	cmd.Flags().Int(flagConcurrency, defaultConcurrency, "Number of events resent in parallel")
+	cmd.Flags().Float64(flagRate, defaultRate, "Maximum number of events resent per second, 0 for no limit")
+	cmd.Flags().String(flagCheckpoint, "", "File recording resent events, so an interrupted run can be resumed")
+
+	return cmd
+}
+
+type resendSummary struct {
+	Matched    int `json:"matched"`
+	Skipped    int `json:"skipped"`
+	Resent     int `json:"resent"`
+	Failed     int `json:"failed"`
+	Unrecorded int `json:"unrecorded,omitempty"`
+}
+
+func resendFailed(ctx context.Context, cmd *cobra.Command, client *api.Client) error {
+	listOpts := api.EventListOptions{
+		Type:      viper.GetString(flagType),
+		Status:    api.EventStatusFailed,
+		WebhookID: viper.GetString(flagWebhook),
+	}
+
+	if since := viper.GetDuration(flagSince); since > 0 {
+		listOpts.CreatedAfter = time.Now().Add(-since)
+	}
+
+	events, err := resendCandidates(ctx, client, listOpts)
+	if err != nil {
+		return err
+	}
+
+	cp, err := openCheckpoint(viper.GetString(flagCheckpoint))
+	if err != nil {
+		return err
+	}
+
+	defer cp.Close()
+
+	summary := resendSummary{Matched: len(events)}
+
+	pending := events[:0]
+	for _, ev := range events {
+		if cp.Has(ev.ID) {
+			summary.Skipped++
+			continue
+		}
+
+		pending = append(pending, ev)
+	}
+
+	if len(pending) == 0 {
+		cmd.PrintErrf("No failed events to resend (%d matched, %d already resent)\n", summary.Matched, summary.Skipped)
+		return printResendSummary(cmd, summary)
+	}
+
+	printResendSample(cmd, pending, summary.Skipped)
+
+	if !viper.GetBool(flagYes) {
+		if !isInteractive(cmd) {
+			return &UsageError{Err: errors.New("confirmation required, pass --yes to resend without prompting")}
+		}
+
+		ok, err := promptConfirmation(fmt.Sprintf("Resend %d events?", len(pending)), false)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errors.New("did not confirm")
+		}
+	}
+
+	bar := progress.New(cmd.ErrOrStderr(), len(pending), colorEnabled(cmd.ErrOrStderr()))
+	limiter := ratelimit.New(viper.GetFloat64(flagRate))
+
+	summary.Resent, summary.Failed, summary.Unrecorded = resendAll(
+		ctx, client, pending, listOpts.WebhookID, viper.GetInt(flagConcurrency), limiter, bar, cp,
+	)
+	bar.Finish()
+
+	if err := printResendSummary(cmd, summary); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return errors.New("interrupted")
+	}
+
+	if summary.Failed > 0 {
+		return fmt.Errorf("%s could not be resent", plural(summary.Failed, "event"))
+	}
+
+	if summary.Unrecorded > 0 {
+		return fmt.Errorf(
+			"%s could not be recorded in the checkpoint, they would be resent again on resume",
+			plural(summary.Unrecorded, "resent event"),
+		)
+	}
+
+	return nil
+}
+
+// resendCandidates returns the failed events matching opts. With a webhook,
+// those are the events whose latest attempt to it failed for good, whatever
+// happened to their deliveries to other webhooks.
+func resendCandidates(ctx context.Context, client *api.Client, opts api.EventListOptions) ([]api.Event, error) {
+	if opts.WebhookID == "" {
+		return collectEvents(ctx, client, opts)
+	}
+
+	attempts, err := collectAttempts(ctx, func(ctx context.Context, o api.AttemptListOptions) ([]api.Attempt, *api.Pagination, error) {
+		return client.ListWebhookAttempts(ctx, opts.WebhookID, o)
+	}, api.AttemptListOptions{CreatedAfter: opts.CreatedAfter})
+	if err != nil {
+		return nil, err
+	}
+
+	failed := failedOnWebhook(attempts)
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	opts.Status = ""
+
+	events, err := collectEvents(ctx, client, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := events[:0]
+	for _, ev := range events {
+		if failed[ev.ID] {
+			candidates = append(candidates, ev)
+		}
+	}
+
+	return candidates, nil
+}
+
+// failedOnWebhook returns the IDs of the events whose latest attempt among
+// attempts failed with no retry planned.
+func failedOnWebhook(attempts []api.Attempt) map[string]bool {
+	latest := map[string]api.Attempt{}
+
+	for _, a := range attempts {
+		if last, ok := latest[a.EventID]; !ok || !a.CreatedAt.Before(last.CreatedAt) {
+			latest[a.EventID] = a
+		}
+	}
+
+	failed := map[string]bool{}
+
+	for id, a := range latest {
+		if a.Status == api.AttemptStatusFailed && a.NextRetryAt == nil {
+			failed[id] = true
+		}
+	}
+
+	return failed
+}
+
+func collectEvents(ctx context.Context, client *api.Client, opts api.EventListOptions) ([]api.Event, error) {
+	var all []api.Event
+
+	for page := 1; ; page++ {
+		opts.Page = page
+
+		events, p, err := client.ListEvents(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, events...)
+
+		if !p.HasNext() {
+			return all, nil
+		}
+	}
+}
+
+func printResendSample(cmd *cobra.Command, events []api.Event, skipped int) {
+	cmd.PrintErrf("%s to resend", plural(len(events), "failed event"))
+
+	if skipped > 0 {
+		cmd.PrintErrf(", %d more already resent according to the checkpoint", skipped)
+	}
+
+	cmd.PrintErrln()
+
+	w := tabwriter.NewWriter(cmd.ErrOrStderr(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTYPE\tCREATED")
+
+	for i, ev := range events {
+		if i == resendSampleSize {
+			fmt.Fprintf(w, "... and %d more\t\t\n", len(events)-resendSampleSize)
+			break
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", ev.ID, ev.Type, ev.CreatedAt.Local().Format(time.RFC3339))
+	}
+
+	_ = w.Flush()
+}
+
+func printResendSummary(cmd *cobra.Command, summary resendSummary) error {
+	if ok, err := printStructured(cmd.OutOrStdout(), summary); ok {
+		return err
+	}
+
+	cmd.Printf("Resent %s, %d failed, %d skipped\n", plural(summary.Resent, "event"), summary.Failed, summary.Skipped)
+
+	if summary.Unrecorded > 0 {
+		cmd.Printf("%s missing from the checkpoint\n", plural(summary.Unrecorded, "resent event"))
+	}
+
+	return nil
+}
+
+// resendAll resends events with at most concurrency requests in flight, only
+// to webhookID when it is set. It returns how many were resent, how many
+// failed and how many were resent but could not be recorded in cp.
+func resendAll(
+	ctx context.Context,
+	client *api.Client,
+	events []api.Event,
+	webhookID string,
+	concurrency int,
+	limiter *ratelimit.Limiter,
+	bar *progress.Bar,
+	cp *checkpoint,
+) (resent, failed, unrecorded int) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+
+	ids := make(chan string)
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for id := range ids {
+				var err error
+				if webhookID != "" {
+					_, err = client.ResendEventToWebhook(ctx, id, webhookID)
+				} else {
+					_, err = client.ResendEvent(ctx, id)
+				}
+
+				var markErr error
+				if err == nil {
+					markErr = cp.Mark(id)
+				}
+
+				mu.Lock()
+				switch {
+				case err != nil:
+					failed++
+				case markErr != nil:
+					resent++
+					unrecorded++
+				default:
+					resent++
+				}
+				mu.Unlock()
+
+				if err != nil && ctx.Err() == nil {
+					bar.Printf("%s: %v\n", id, err)
+				}
+
+				if markErr != nil {
+					bar.Printf("%s: resent, but writing the checkpoint failed: %v\n", id, markErr)
+				}
+
+				bar.Add(err == nil)
+			}
+		}()
+	}
+
+	for _, ev := range events {
+		if limiter.Wait(ctx) != nil {
+			break
+		}
+
+		ids <- ev.ID
+	}
+
+	close(ids)
+	wg.Wait()
+
+	return resent, failed, unrecorded
+}
+
+func isInteractive(cmd *cobra.Command) bool {
+	f, ok := cmd.InOrStdin().(*os.File)
+	return ok && isatty.IsTerminal(f.Fd())
+}
+
+// checkpoint is an append-only file with the ID of every event already
+// handled, one per line. A nil checkpoint records nothing.
+type checkpoint struct {
+	mu   sync.Mutex
+	f    *os.File
+	done map[string]struct{}
+}
+
+func openCheckpoint(path string) (*checkpoint, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, err
+	}
+
+	cp := &checkpoint{f: f, done: map[string]struct{}{}}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if id := strings.TrimSpace(scanner.Text()); id != "" {
+			cp.done[id] = struct{}{}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading checkpoint: %w", err)
+	}
+
+	return cp, nil
+}
+
+func (c *checkpoint) Has(id string) bool {
+	if c == nil {
+		return false
+	}
+
+	_, ok := c.done[id]
+
+	return ok
+}
+
+func (c *checkpoint) Mark(id string) error {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.done[id] = struct{}{}
+	_, err := fmt.Fprintln(c.f, id)
+
+	return err
+}
+
+func (c *checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+
+	return c.f.Close()
+}
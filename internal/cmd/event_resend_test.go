@@ -0,0 +1,197 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/mock"
+)
+
+// newMockAPI starts the mock API, which gives up failed deliveries at once,
+// and returns a client for it and its configuration.
+func newMockAPI(t *testing.T) (*api.Client, *config.Config) {
+	t.Helper()
+
+	handler, err := mock.New(mock.Options{RetryDelays: []time.Duration{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(func() {
+		srv.Close()
+		handler.Close()
+	})
+
+	cfg := &config.Config{Account: "acc_1", AccessToken: "t", BaseURL: srv.URL}
+
+	client, err := api.NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return client, cfg
+}
+
+// newSubscribedWebhook creates a webhook subscribed to every event, whose
+// endpoint answers with status.
+func newSubscribedWebhook(t *testing.T, client *api.Client, status int) string {
+	t.Helper()
+
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(endpoint.Close)
+
+	ctx := context.Background()
+
+	wh, err := client.CreateWebhook(ctx, api.WebhookParams{URL: endpoint.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.CreateSubscription(ctx, api.SubscriptionParams{WebhookID: wh.ID, EventType: "*"}); err != nil {
+		t.Fatal(err)
+	}
+
+	return wh.ID
+}
+
+// publishSettled publishes an event and waits for its deliveries to end.
+func publishSettled(t *testing.T, client *api.Client, eventType string) *api.Event {
+	t.Helper()
+
+	ctx := context.Background()
+
+	ev, err := client.CreateEvent(ctx, api.EventParams{Type: eventType, Data: json.RawMessage(`{}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+
+	for ev.Status == api.EventStatusPending {
+		if time.Now().After(deadline) {
+			t.Fatalf("event %s is still pending", ev.ID)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+
+		if ev, err = client.GetEvent(ctx, ev.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return ev
+}
+
+func TestResendCandidates(t *testing.T) {
+	client, _ := newMockAPI(t)
+
+	ok := newSubscribedWebhook(t, client, http.StatusOK)
+	failing := newSubscribedWebhook(t, client, http.StatusInternalServerError)
+
+	// Delivered to ok, failed on failing, so failed overall.
+	ev := publishSettled(t, client, "order.created")
+	if ev.Status != api.EventStatusFailed {
+		t.Fatalf("event status %s, want %s", ev.Status, api.EventStatusFailed)
+	}
+
+	tests := []struct {
+		name    string
+		webhook string
+		want    []string
+	}{
+		{name: "any webhook", want: []string{ev.ID}},
+		{name: "failed on the webhook", webhook: failing, want: []string{ev.ID}},
+		{name: "succeeded on the webhook, failed elsewhere", webhook: ok},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := resendCandidates(context.Background(), client, api.EventListOptions{
+				Status:    api.EventStatusFailed,
+				WebhookID: tt.webhook,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for _, ev := range events {
+				got = append(got, ev.ID)
+			}
+
+			if !equalStrings(got, tt.want) {
+				t.Fatalf("candidates %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFailedOnWebhook(t *testing.T) {
+	at := func(seconds int) time.Time { return time.Unix(int64(1700000000+seconds), 0) }
+	retry := at(100)
+
+	attempts := []api.Attempt{
+		// Failed, then succeeded on retry.
+		{EventID: "evt_1", Status: api.AttemptStatusFailed, CreatedAt: at(0), NextRetryAt: &retry},
+		{EventID: "evt_1", Status: api.AttemptStatusSucceeded, CreatedAt: at(1)},
+		// Failed for good, listed out of order.
+		{EventID: "evt_2", Status: api.AttemptStatusFailed, CreatedAt: at(2)},
+		{EventID: "evt_2", Status: api.AttemptStatusFailed, CreatedAt: at(1), NextRetryAt: &retry},
+		// Still being retried.
+		{EventID: "evt_3", Status: api.AttemptStatusFailed, CreatedAt: at(0), NextRetryAt: &retry},
+		// Succeeded, then failed when resent.
+		{EventID: "evt_4", Status: api.AttemptStatusSucceeded, CreatedAt: at(0)},
+		{EventID: "evt_4", Status: api.AttemptStatusFailed, CreatedAt: at(5)},
+	}
+
+	var got []string
+	for id := range failedOnWebhook(attempts) {
+		got = append(got, id)
+	}
+
+	sort.Strings(got)
+
+	if want := []string{"evt_2", "evt_4"}; !equalStrings(got, want) {
+		t.Fatalf("failed events %v, want %v", got, want)
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
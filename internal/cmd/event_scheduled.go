@@ -0,0 +1,147 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func NewCmdEventScheduled(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "scheduled",
+		Short: "Manage events scheduled for later delivery",
+	}
+
+	cmd.AddCommand(NewCmdEventScheduledList(opts))
+
+	return cmd
+}
+
+func NewCmdEventScheduledList(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List scheduled events, the next to be delivered first",
+		Args:  cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo event scheduled list
+			xibugo event scheduled list --type 'reminder.*'
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			events, err := collectEvents(cmd.Context(), client, api.EventListOptions{
+				ListOptions: api.ListOptions{PerPage: api.DefaultPerPage},
+				Type:        viper.GetString(flagType),
+				Status:      api.EventStatusScheduled,
+			})
+			if err != nil {
+				return err
+			}
+
+			sort.SliceStable(events, func(i, j int) bool {
+				return deliverAt(events[i]).Before(deliverAt(events[j]))
+			})
+
+			if ok, err := printStructured(cmd.OutOrStdout(), events); ok {
+				return err
+			}
+
+			now := time.Now()
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tTYPE\tDELIVER AT\tIN")
+
+			for _, ev := range events {
+				at := deliverAt(ev)
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", ev.ID, ev.Type, at.Local().Format(time.RFC3339), at.Sub(now).Round(time.Second))
+			}
+
+			return w.Flush()
+		},
+	}
+
+	cmd.Flags().String(flagType, "", "Only list events of this type, may be a pattern such as 'reminder.*'")
+
+	return cmd
+}
+
+// deliveryTime returns when an event is to be delivered according to
+// --deliver-at or --delay, or nil to deliver it right away.
+func deliveryTime(cmd *cobra.Command) (*time.Time, error) {
+	var at time.Time
+
+	switch {
+	case cmd.Flags().Changed(flagDeliverAt):
+		var err error
+		if at, err = time.Parse(time.RFC3339, viper.GetString(flagDeliverAt)); err != nil {
+			return nil, &UsageError{Err: errors.New("--deliver-at must be an RFC 3339 time, such as 2026-11-01T09:00:00Z")}
+		}
+
+		if !at.After(time.Now()) {
+			return nil, &UsageError{Err: errors.New("--deliver-at must be in the future")}
+		}
+	case cmd.Flags().Changed(flagDelay):
+		delay := viper.GetDuration(flagDelay)
+		if delay <= 0 {
+			return nil, &UsageError{Err: errors.New("--delay must be positive")}
+		}
+
+		at = time.Now().Add(delay)
+	default:
+		return nil, nil
+	}
+
+	at = at.UTC()
+
+	return &at, nil
+}
+
+// deliverAt returns when a scheduled event is due, which is when it was
+// created for events scheduled without a time.
+func deliverAt(ev api.Event) time.Time {
+	if ev.DeliverAt != nil {
+		return *ev.DeliverAt
+	}
+
+	return ev.CreatedAt
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+func TestDeliveryTime(t *testing.T) {
+	future := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	tests := []struct {
+		name  string
+		args  []string
+		want  time.Duration
+		at    *time.Time
+		usage bool
+	}{
+		{
+			name: "right away",
+		},
+		{
+			name: "deliver at",
+			args: []string{"--deliver-at", future.Format(time.RFC3339)},
+			at:   &future,
+		},
+		{
+			name: "deliver at with an offset",
+			args: []string{"--deliver-at", future.In(time.FixedZone("", 2*60*60)).Format(time.RFC3339)},
+			at:   &future,
+		},
+		{
+			name:  "deliver at in the past",
+			args:  []string{"--deliver-at", time.Now().Add(-time.Minute).Format(time.RFC3339)},
+			usage: true,
+		},
+		{
+			name:  "deliver at without a zone",
+			args:  []string{"--deliver-at", "2026-11-01T09:00:00"},
+			usage: true,
+		},
+		{
+			name:  "deliver at a date",
+			args:  []string{"--deliver-at", "2026-11-01"},
+			usage: true,
+		},
+		{
+			name: "delay",
+			args: []string{"--delay", "15m"},
+			want: 15 * time.Minute,
+		},
+		{
+			name:  "zero delay",
+			args:  []string{"--delay", "0s"},
+			usage: true,
+		},
+		{
+			name:  "negative delay",
+			args:  []string{"--delay", "-5m"},
+			usage: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(viper.Reset)
+
+			cmd := &cobra.Command{}
+			cmd.Flags().String(flagDeliverAt, "", "")
+			cmd.Flags().Duration(flagDelay, 0, "")
+
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+
+			at, err := deliveryTime(cmd)
+			if tt.usage {
+				var usageErr *UsageError
+				if !errors.As(err, &usageErr) {
+					t.Fatalf("got %v, want a usage error", err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			switch {
+			case tt.at != nil:
+				if at == nil || !at.Equal(*tt.at) || at.Location() != time.UTC {
+					t.Errorf("got %v, want %v in UTC", at, tt.at)
+				}
+			case tt.want != 0:
+				if at == nil || at.Before(start.Add(tt.want)) || at.After(time.Now().Add(tt.want)) {
+					t.Errorf("got %v, want %v from now", at, tt.want)
+				}
+			case at != nil:
+				t.Errorf("got %v, want no delivery time", at)
+			}
+		})
+	}
+}
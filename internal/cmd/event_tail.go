@@ -0,0 +1,372 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	defaultTailInterval = 2 * time.Second
+	// tailOverlap is how far before the last change seen events and
+	// attempts are fetched again, so that changes recorded out of order are
+	// not missed. Those already printed are recognised and skipped.
+	tailOverlap = 5 * time.Second
+
+	tailKindEvent   = "event"
+	tailKindAttempt = "attempt"
+)
+
+// tailStatus is what a --status value selects. Events and attempts have
+// different statuses; an empty one means no entry of that kind matches.
+type tailStatus struct {
+	event   string
+	attempt string
+}
+
+var tailStatuses = map[string]tailStatus{
+	api.EventStatusScheduled:   {event: api.EventStatusScheduled},
+	api.EventStatusPending:     {event: api.EventStatusPending},
+	api.EventStatusDelivered:   {event: api.EventStatusDelivered, attempt: api.AttemptStatusSucceeded},
+	api.AttemptStatusSucceeded: {event: api.EventStatusDelivered, attempt: api.AttemptStatusSucceeded},
+	api.EventStatusFailed:      {event: api.EventStatusFailed, attempt: api.AttemptStatusFailed},
+	api.EventStatusCancelled:   {event: api.EventStatusCancelled},
+}
+
+// parseTailStatus returns the statuses selected by --status, or nil when
+// every status is shown.
+func parseTailStatus(value string) (*tailStatus, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	status, ok := tailStatuses[value]
+	if !ok {
+		names := make([]string, 0, len(tailStatuses))
+		for name := range tailStatuses {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+
+		return nil, &UsageError{Err: fmt.Errorf("invalid --status %q, expected one of: %s", value, strings.Join(names, ", "))}
+	}
+
+	return &status, nil
+}
+
+func NewCmdEventTail(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tail",
+		Short: "Follow new events and delivery attempts",
+		Args:  cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo event tail
+			xibugo event tail --type 'order.*' --status failed
+			xibugo event tail --webhook 123 --since 1h -o json | jq .
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			status, err := parseTailStatus(viper.GetString(flagStatus))
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			start := time.Now().Add(-viper.GetDuration(flagSince))
+
+			t := &tailer{
+				client:        client,
+				eventType:     viper.GetString(flagType),
+				status:        status,
+				seen:          map[string]seenEvent{},
+				seenAttempts:  map[string]time.Time{},
+				webhookID:     viper.GetString(flagWebhook),
+				from:          start,
+				eventsAfter:   start,
+				attemptsAfter: start,
+				out:           cmd.OutOrStdout(),
+				json:          outputFormat() == formatJSON,
+				color:         colorEnabled(cmd.OutOrStdout()),
+			}
+
+			return t.run(ctx, cmd, viper.GetDuration(flagInterval))
+		},
+	}
+
+	cmd.Flags().String(flagType, "", "Only show events of this type, may be a pattern such as 'order.*'")
+	cmd.Flags().String(flagStatus, "", "Only show events and attempts with this status: scheduled, pending, delivered, failed or cancelled")
+	cmd.Flags().String(flagWebhook, "", "Only show deliveries to this webhook")
+	cmd.Flags().Duration(flagSince, 0, "Also show what happened this long before starting")
+	cmd.Flags().Duration(flagInterval, defaultTailInterval, "How often to poll for changes")
+
+	return cmd
+}
+
+type tailer struct {
+	client    *api.Client
+	eventType string
+	status    *tailStatus
+	webhookID string
+
+	// from is when the output starts; what changed before is not shown.
+	from          time.Time
+	eventsAfter   time.Time
+	attemptsAfter time.Time
+
+	// seen holds the status last seen of the events changed lately, so that
+	// an event is shown again when its status changes, and only then.
+	seen map[string]seenEvent
+	// seenAttempts holds when the attempts fetched lately were made, by ID,
+	// so that those fetched again within the overlap are shown once.
+	seenAttempts map[string]time.Time
+
+	out   io.Writer
+	json  bool
+	color bool
+}
+
+type seenEvent struct {
+	status    string
+	updatedAt time.Time
+}
+
+// tailEntry is a line of output, as written with --output json.
+type tailEntry struct {
+	Kind    string       `json:"kind"`
+	Event   *api.Event   `json:"event,omitempty"`
+	Attempt *api.Attempt `json:"attempt,omitempty"`
+
+	at time.Time
+}
+
+func (t *tailer) run(ctx context.Context, cmd *cobra.Command, interval time.Duration) error {
+	for {
+		if err := t.poll(ctx); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if !reconnectable(err) {
+				return err
+			}
+
+			cmd.PrintErrf("Polling failed, retrying: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
+
+// poll fetches what happened since the previous poll and prints it in
+// chronological order. Events are printed when they are created and each
+// time their status changes, so that one failing after it was first seen
+// pending is reported.
+func (t *tailer) poll(ctx context.Context) error {
+	events, err := t.fetchEvents(ctx)
+	if err != nil {
+		return err
+	}
+
+	attempts, err := t.fetchAttempts(ctx)
+	if err != nil {
+		return err
+	}
+
+	var entries []tailEntry
+
+	for i := range events {
+		ev := &events[i]
+
+		if ev.UpdatedAt.After(t.eventsAfter) {
+			t.eventsAfter = ev.UpdatedAt
+		}
+
+		if seen, ok := t.seen[ev.ID]; ok && seen.status == ev.Status {
+			continue
+		}
+
+		t.seen[ev.ID] = seenEvent{status: ev.Status, updatedAt: ev.UpdatedAt}
+
+		if !ev.UpdatedAt.After(t.from) || (t.status != nil && ev.Status != t.status.event) {
+			continue
+		}
+
+		entries = append(entries, tailEntry{Kind: tailKindEvent, Event: ev, at: ev.UpdatedAt})
+	}
+
+	// Events older than the overlap are not fetched again unless they
+	// change, so there is no need to remember them.
+	for id, seen := range t.seen {
+		if seen.updatedAt.Before(t.eventsAfter.Add(-tailOverlap)) {
+			delete(t.seen, id)
+		}
+	}
+
+	for i := range attempts {
+		a := &attempts[i]
+
+		if a.CreatedAt.After(t.attemptsAfter) {
+			t.attemptsAfter = a.CreatedAt
+		}
+
+		if _, ok := t.seenAttempts[a.ID]; ok {
+			continue
+		}
+
+		t.seenAttempts[a.ID] = a.CreatedAt
+
+		if !a.CreatedAt.After(t.from) {
+			continue
+		}
+
+		entries = append(entries, tailEntry{Kind: tailKindAttempt, Attempt: a, at: a.CreatedAt})
+	}
+
+	for id, createdAt := range t.seenAttempts {
+		if createdAt.Before(t.attemptsAfter.Add(-tailOverlap)) {
+			delete(t.seenAttempts, id)
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].at.Before(entries[j].at)
+	})
+
+	for _, e := range entries {
+		if err := t.print(e); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchEvents lists the events changed since the previous poll, whatever
+// their status, as the status filter applies to their latest one.
+func (t *tailer) fetchEvents(ctx context.Context) ([]api.Event, error) {
+	return collectEvents(ctx, t.client, api.EventListOptions{
+		Type:         t.eventType,
+		WebhookID:    t.webhookID,
+		UpdatedAfter: t.eventsAfter.Add(-tailOverlap),
+	})
+}
+
+// fetchAttempts lists the attempts made since the previous poll. Like events,
+// they are fetched again for a while, as one may be recorded after a later
+// one was listed.
+func (t *tailer) fetchAttempts(ctx context.Context) ([]api.Attempt, error) {
+	if t.status != nil && t.status.attempt == "" {
+		return nil, nil
+	}
+
+	var all []api.Attempt
+
+	opts := api.AttemptListOptions{
+		EventType:    t.eventType,
+		WebhookID:    t.webhookID,
+		CreatedAfter: t.attemptsAfter.Add(-tailOverlap),
+	}
+
+	if t.status != nil {
+		opts.Status = t.status.attempt
+	}
+
+	for page := 1; ; page++ {
+		opts.Page = page
+
+		attempts, p, err := t.client.ListAttempts(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, attempts...)
+
+		if !p.HasNext() {
+			return all, nil
+		}
+	}
+}
+
+func (t *tailer) print(e tailEntry) error {
+	if t.json {
+		return json.NewEncoder(t.out).Encode(e)
+	}
+
+	at := e.at.Local().Format("15:04:05")
+
+	if ev := e.Event; ev != nil {
+		_, err := fmt.Fprintf(t.out, "%s  event    %-14s %-24s %s\n", at, ev.ID, ev.Type, colorStatus(ev.Status, t.color))
+		return err
+	}
+
+	a := e.Attempt
+
+	outcome := fmt.Sprintf("%d %dms", a.ResponseStatus, a.DurationMS)
+	if a.Error != "" {
+		outcome = a.Error
+	}
+
+	if a.NextRetryAt != nil {
+		outcome += fmt.Sprintf(" (retry at %s)", a.NextRetryAt.Local().Format("15:04:05"))
+	}
+
+	_, err := fmt.Fprintf(
+		t.out,
+		"%s  attempt  %-14s %-24s %-10s %s  %s\n",
+		at, a.EventID, a.EventType, a.WebhookID, colorStatus(a.Status, t.color), outcome,
+	)
+
+	return err
+}
@@ -0,0 +1,119 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+)
+
+// attemptLog stands in for the API, listing no events and the attempts
+// given to it so far, ignoring filters, as the tailer filters them itself.
+type attemptLog struct {
+	mu       sync.Mutex
+	attempts []api.Attempt
+}
+
+func (l *attemptLog) add(a api.Attempt) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.attempts = append(l.attempts, a)
+}
+
+func (l *attemptLog) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var data interface{} = []api.Event{}
+	if strings.HasSuffix(r.URL.Path, "/attempts") {
+		data = l.attempts
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+}
+
+func TestTailAttemptsRecordedOutOfOrder(t *testing.T) {
+	log := &attemptLog{}
+	srv := httptest.NewServer(log)
+	t.Cleanup(srv.Close)
+
+	client, err := api.NewClient(&config.Config{Account: "acc_1", AccessToken: "t", BaseURL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now().Add(-time.Minute)
+	at := func(seconds int) time.Time { return start.Add(time.Duration(seconds) * time.Second) }
+
+	var out bytes.Buffer
+
+	tail := &tailer{
+		client:        client,
+		seen:          map[string]seenEvent{},
+		seenAttempts:  map[string]time.Time{},
+		from:          start,
+		eventsAfter:   start,
+		attemptsAfter: start,
+		out:           &out,
+		json:          true,
+	}
+
+	poll := func() {
+		t.Helper()
+
+		if err := tail.poll(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	log.add(api.Attempt{ID: "dlv_1", Status: api.AttemptStatusSucceeded, CreatedAt: at(10)})
+	poll()
+
+	// Recorded after dlv_1 was listed: one with the same time, one earlier.
+	log.add(api.Attempt{ID: "dlv_2", Status: api.AttemptStatusFailed, CreatedAt: at(10)})
+	log.add(api.Attempt{ID: "dlv_3", Status: api.AttemptStatusFailed, CreatedAt: at(8)})
+	poll()
+	poll()
+
+	var got []string
+
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var e tailEntry
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+
+		got = append(got, e.Attempt.ID)
+	}
+
+	if want := []string{"dlv_1", "dlv_3", "dlv_2"}; !equalStrings(got, want) {
+		t.Fatalf("printed attempts %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"go/token"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/codegen"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagLang    = "lang"
+	flagPackage = "package"
+	flagOut     = "out"
+
+	langGo = "go"
+)
+
+func NewCmdEventTypeCodegen(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "codegen",
+		Short: "Generate types for event data from event type schemas",
+		Long: heredoc.Doc(`
+			Generate a package with a type per event type, derived from its JSON
+			Schema, and a dispatcher that decodes webhook deliveries into them.
+
+			Regenerating after a schema change makes consumers that rely on a
+			removed or renamed field fail to compile.
+		`),
+		Args: cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo event-type codegen --lang go --package events --out ./events
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			if lang := viper.GetString(flagLang); lang != langGo {
+				return &UsageError{Err: fmt.Errorf("unsupported language %q, only %s is supported", lang, langGo)}
+			}
+
+			pkg := viper.GetString(flagPackage)
+			if !token.IsIdentifier(pkg) {
+				return &UsageError{Err: fmt.Errorf("invalid package name %q", pkg)}
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			eventTypes, err := collectEventTypes(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+
+			files, err := codegen.Go(pkg, eventTypes)
+			if err != nil {
+				return err
+			}
+
+			out := viper.GetString(flagOut)
+			if err := os.MkdirAll(out, 0o755); err != nil {
+				return err
+			}
+
+			for _, f := range files {
+				path := filepath.Join(out, f.Name)
+				if err := os.WriteFile(path, f.Content, 0o644); err != nil {
+					return err
+				}
+
+				cmd.Printf("Wrote %s\n", path)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagLang, langGo, "Language to generate")
+	cmd.Flags().String(flagPackage, "events", "Name of the generated package")
+	cmd.Flags().String(flagOut, ".", "Directory to write the generated files to")
+
+	return cmd
+}
+
+func collectEventTypes(ctx context.Context, client *api.Client) ([]api.EventType, error) {
+	var all []api.EventType
+
+	opts := api.ListOptions{PerPage: api.DefaultPerPage}
+
+	for opts.Page = 1; ; opts.Page++ {
+		eventTypes, p, err := client.ListEventTypes(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, eventTypes...)
+
+		if !p.HasNext() {
+			return all, nil
+		}
+	}
+}
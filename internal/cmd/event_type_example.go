@@ -0,0 +1,112 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagExample = "example"
+
+func NewCmdEventTypeExample(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "example <event-type>",
+		Short: "Generate sample event data from the schema of an event type",
+		Long: heredoc.Doc(`
+			Generate sample event data from the JSON Schema of an event type. Values
+			from examples, default, const and enum are used as they are, and the
+			rest is made up from the type, format and bounds of each field.
+
+			A warning is printed when the schema has constraints the generated data
+			does not satisfy, such as complex patterns.
+		`),
+		Args: cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			xibugo event-type example order.created
+			xibugo event-type example order.created > order.json
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			data, err := exampleData(cmd.Context(), cfg, client, args[0])
+			if err != nil {
+				return err
+			}
+
+			var validationErr *schema.ValidationError
+			if err := validatePayload(cmd.Context(), cfg, client, args[0], data); errors.As(err, &validationErr) {
+				cmd.PrintErrln("Warning: the example does not fully match the schema, adjust it by hand:")
+
+				for _, e := range validationErr.Errors {
+					cmd.PrintErrf("  - %s\n", e)
+				}
+			} else if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), data); ok {
+				return err
+			}
+
+			cmd.Println(string(data))
+
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// exampleData generates sample data for an event type from its schema.
+func exampleData(ctx context.Context, cfg *config.Config, client *api.Client, eventType string) (json.RawMessage, error) {
+	s, err := eventSchema(ctx, cfg, client, eventType)
+	if err != nil {
+		return nil, err
+	}
+
+	if s == nil {
+		return nil, fmt.Errorf("event type %s has no schema to generate an example from", eventType)
+	}
+
+	return s.Example()
+}
@@ -0,0 +1,302 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagAgainst = "against"
+
+// schemaDiff is the structured output of "event-type schema diff".
+type schemaDiff struct {
+	EventType  string          `json:"event_type"`
+	Version    int             `json:"version"`
+	Compatible bool            `json:"compatible"`
+	Changes    []schema.Change `json:"changes"`
+}
+
+// schemaHistoryEntry is a schema version along with its changes from the
+// previous version.
+type schemaHistoryEntry struct {
+	api.SchemaVersion
+	Changes []schema.Change `json:"changes"`
+}
+
+func NewCmdEventTypeSchema(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "schema",
+		Short: "Compare and inspect the versions of event type schemas",
+	}
+
+	cmd.AddCommand(NewCmdEventTypeSchemaDiff(opts))
+	cmd.AddCommand(NewCmdEventTypeSchemaHistory(opts))
+
+	return cmd
+}
+
+func NewCmdEventTypeSchemaDiff(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <event-type>",
+		Short: "Classify the changes from the current schema to a new one",
+		Long: heredoc.Doc(`
+			Compare the current schema of an event type with a new one and
+			classify every change as backward-compatible or breaking.
+
+			Removed fields, type changes, new required fields and tighter
+			constraints are breaking. The command exits with code 11 when
+			any change is breaking, so it can gate schema changes in CI.
+		`),
+		Example: heredoc.Doc(`
+			xibugo event-type schema diff order.created --against order.schema.json
+			xibugo event-type schema diff order.created --against order.schema.json -o json
+		`),
+		Args: cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			_, newSchema, err := readSchema(cmd, "@"+viper.GetString(flagAgainst))
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			current, err := client.GetEventType(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			changes, err := compareSchemas(current.Schema, newSchema)
+			if err != nil {
+				return err
+			}
+
+			breaking := schema.Breaking(changes)
+			diff := schemaDiff{
+				EventType:  current.Name,
+				Version:    current.Version,
+				Compatible: len(breaking) == 0,
+				Changes:    changes,
+			}
+
+			if diff.Changes == nil {
+				diff.Changes = []schema.Change{}
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), diff); !ok {
+				printSchemaDiff(cmd.OutOrStdout(), diff)
+			} else if err != nil {
+				return err
+			}
+
+			if len(breaking) > 0 {
+				return &BreakingChangeError{Changes: len(breaking)}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagAgainst, "", "File with the new JSON Schema, - for stdin")
+	_ = cmd.MarkFlagRequired(flagAgainst)
+
+	return cmd
+}
+
+func NewCmdEventTypeSchemaHistory(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history <event-type>",
+		Short: "Show the schema versions of an event type",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			xibugo event-type schema history order.created
+			xibugo event-type schema history order.created -o json
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			versions, err := collectSchemaVersions(cmd.Context(), client, args[0])
+			if err != nil {
+				return err
+			}
+
+			history, err := schemaHistory(versions)
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), history); ok {
+				return err
+			}
+
+			if len(history) == 0 {
+				cmd.Printf("Event type %s has no schema\n", args[0])
+				return nil
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "VERSION\tCREATED\tCHANGES\tBREAKING")
+
+			for _, entry := range history {
+				fmt.Fprintf(w, "%d\t%s\t%d\t%d\n",
+					entry.Version,
+					entry.CreatedAt.Local().Format(time.RFC3339),
+					len(entry.Changes),
+					len(schema.Breaking(entry.Changes)),
+				)
+			}
+
+			return w.Flush()
+		},
+	}
+
+	return cmd
+}
+
+// compareSchemas classifies the changes from the current schema of an event
+// type, which may be empty, to a new one.
+func compareSchemas(current json.RawMessage, newSchema *schema.Schema) ([]schema.Change, error) {
+	var old *schema.Schema
+
+	if hasSchema(current) {
+		var err error
+		if old, err = schema.Compile(current); err != nil {
+			return nil, fmt.Errorf("current schema: %w", err)
+		}
+	}
+
+	return schema.Compare(old, newSchema), nil
+}
+
+func collectSchemaVersions(ctx context.Context, client *api.Client, eventType string) ([]api.SchemaVersion, error) {
+	var all []api.SchemaVersion
+
+	opts := api.ListOptions{PerPage: api.DefaultPerPage}
+
+	for opts.Page = 1; ; opts.Page++ {
+		versions, p, err := client.ListSchemaVersions(ctx, eventType, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, versions...)
+
+		if !p.HasNext() {
+			return all, nil
+		}
+	}
+}
+
+// schemaHistory classifies the changes each version made to the one before.
+func schemaHistory(versions []api.SchemaVersion) ([]schemaHistoryEntry, error) {
+	history := make([]schemaHistoryEntry, 0, len(versions))
+
+	var previous json.RawMessage
+
+	for _, v := range versions {
+		s, err := schema.Compile(v.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("schema version %d: %w", v.Version, err)
+		}
+
+		changes, err := compareSchemas(previous, s)
+		if err != nil {
+			return nil, err
+		}
+
+		if changes == nil {
+			changes = []schema.Change{}
+		}
+
+		history = append(history, schemaHistoryEntry{SchemaVersion: v, Changes: changes})
+		previous = v.Schema
+	}
+
+	return history, nil
+}
+
+func printSchemaDiff(w io.Writer, diff schemaDiff) {
+	if len(diff.Changes) == 0 {
+		fmt.Fprintf(w, "No changes, the schema matches version %d of %s.\n", diff.Version, diff.EventType)
+		return
+	}
+
+	printSchemaChanges(w, diff.Changes)
+
+	breaking := len(schema.Breaking(diff.Changes))
+	fmt.Fprintf(w, "\nChanges: %d breaking, %d compatible.\n", breaking, len(diff.Changes)-breaking)
+}
+
+// printSchemaChanges lists schema changes, marking breaking ones with "!".
+func printSchemaChanges(w io.Writer, changes []schema.Change) {
+	color := colorEnabled(w)
+
+	for _, c := range changes {
+		symbol, ansi := "+", ansiGreen
+		if c.Breaking {
+			symbol, ansi = "!", ansiRed
+		}
+
+		if color {
+			symbol = ansi + symbol + ansiReset
+		}
+
+		fmt.Fprintf(w, "%s %s\n", symbol, c)
+	}
+}
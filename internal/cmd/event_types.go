@@ -17,13 +17,26 @@
 package cmd
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
 	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+const (
+	flagSchema = "schema"
+	flagForce  = "force"
+)
+
 func NewCmdEventType(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:     "event-type",
@@ -35,6 +48,10 @@ func NewCmdEventType(opts *internal.CommandOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdEventTypeDelete(opts))
 	cmd.AddCommand(NewCmdEventTypeCreate(opts))
 	cmd.AddCommand(NewCmdEventTypeGet(opts))
+	cmd.AddCommand(NewCmdEventTypeUpdate(opts))
+	cmd.AddCommand(NewCmdEventTypeSchema(opts))
+	cmd.AddCommand(NewCmdEventTypeCodegen(opts))
+	cmd.AddCommand(NewCmdEventTypeExample(opts))
 
 	return cmd
 }
@@ -43,6 +60,7 @@ func NewCmdEventTypeList(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List event types",
+		Args:  cobra.NoArgs,
 		Example: heredoc.Doc(`
 			xibugo event-type list
 		`),
@@ -59,22 +77,47 @@ func NewCmdEventTypeList(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			eventTypes, _, err := client.ListEventTypes(cmd.Context(), api.ListOptions{
+				Page:    viper.GetInt(flagPage),
+				PerPage: viper.GetInt(flagPerPage),
+			})
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), eventTypes); ok {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tNAME\tSCHEMA\tDESCRIPTION")
+
+			for _, et := range eventTypes {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", et.ID, et.Name, yesNo(hasSchema(et.Schema)), et.Description)
+			}
+
+			return w.Flush()
 		},
 	}
 
+	cmd.Flags().Int(flagPage, 1, "Page to list")
+	cmd.Flags().Int(flagPerPage, api.DefaultPerPage, "Number of event types per page")
+
 	return cmd
 }
 
 func NewCmdEventTypeDelete(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "delete",
-		Short: "Delete an event type type",
-		Args:  cobra.NoArgs,
+		Use:   "delete <event-type>",
+		Short: "Delete an event type",
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
-			xibugo event-type delete 123
+			xibugo event-type delete order.created
 		`),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
@@ -89,7 +132,16 @@ func NewCmdEventTypeDelete(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteEventType(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+
+			cmd.Printf("Deleted event type %s\n", args[0])
 
 			return nil
 		},
@@ -100,12 +152,13 @@ func NewCmdEventTypeDelete(opts *internal.CommandOptions) *cobra.Command {
 
 func NewCmdEventTypeCreate(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "create",
-		Short: "Create an event type type",
+		Use:   "create <name>",
+		Short: "Create an event type",
 		Example: heredoc.Doc(`
-			xibugo event-type create --webhook 123
+			xibugo event-type create order.created
+			xibugo event-type create order.created --description 'An order was placed' --schema @order.schema.json
 		`),
-		Args: cobra.MaximumNArgs(1),
+		Args: cobra.ExactArgs(1),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
 				panic(err)
@@ -114,27 +167,155 @@ func NewCmdEventTypeCreate(opts *internal.CommandOptions) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			internal.SetupIO(cmd, opts)
 
+			params := api.EventTypeParams{
+				Name:        args[0],
+				Description: viper.GetString(flagDescription),
+			}
+
+			if value := viper.GetString(flagSchema); value != "" {
+				raw, _, err := readSchema(cmd, value)
+				if err != nil {
+					return err
+				}
+
+				params.Schema = raw
+			}
+
 			cfg, err := config.New()
 			if err != nil {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			eventType, err := client.CreateEventType(cmd.Context(), params)
+			if err != nil {
+				return err
+			}
+
+			forgetSchema(cfg, eventType.Name)
+
+			return printEventType(cmd, eventType)
+		},
+	}
+
+	cmd.Flags().String(flagDescription, "", "Description of the event type")
+	cmd.Flags().String(flagSchema, "", "JSON Schema of the event data, inline or as @file")
+
+	return cmd
+}
+
+func NewCmdEventTypeUpdate(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "update <event-type>",
+		Short: "Update the description or schema of an event type",
+		Long: heredoc.Doc(`
+			Update the description or schema of an event type.
+
+			A new schema is compared with the current one first. Breaking
+			changes, such as removed fields, type changes or new required
+			fields, are refused unless --force is given. Every schema change
+			is kept as a new version, see "xibugo event-type schema history".
+		`),
+		Example: heredoc.Doc(`
+			xibugo event-type update order.created --description 'An order was placed'
+			xibugo event-type update order.created --schema @order.schema.json
+			xibugo event-type update order.created --schema @order.schema.json --force
+		`),
+		Args: cobra.ExactArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			if !cmd.Flags().Changed(flagDescription) && !cmd.Flags().Changed(flagSchema) {
+				return &UsageError{Err: errors.New("nothing to update, pass --description or --schema")}
+			}
+
+			var (
+				raw       json.RawMessage
+				newSchema *schema.Schema
+			)
+
+			if cmd.Flags().Changed(flagSchema) {
+				var err error
+				if raw, newSchema, err = readSchema(cmd, viper.GetString(flagSchema)); err != nil {
+					return err
+				}
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			current, err := client.GetEventType(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			params := api.EventTypeParams{Description: current.Description, Schema: raw}
+			if cmd.Flags().Changed(flagDescription) {
+				params.Description = viper.GetString(flagDescription)
+			}
+
+			if newSchema != nil {
+				changes, err := compareSchemas(current.Schema, newSchema)
+				if err != nil {
+					return err
+				}
+
+				if breaking := schema.Breaking(changes); len(breaking) > 0 {
+					if !viper.GetBool(flagForce) {
+						printSchemaChanges(cmd.ErrOrStderr(), breaking)
+						cmd.PrintErrln("Pass --force to update the schema anyway.")
+
+						return &BreakingChangeError{Changes: len(breaking)}
+					}
+
+					cmd.PrintErrf("Warning: forcing %s\n", plural(len(breaking), "breaking schema change"))
+				}
+			}
+
+			eventType, err := client.UpdateEventType(cmd.Context(), current.ID, params)
+			if err != nil {
+				return err
+			}
+
+			// Events published from here on are validated against the new
+			// schema rather than a cached copy of the old one.
+			forgetSchema(cfg, eventType.Name)
+
+			return printEventType(cmd, eventType)
 		},
 	}
 
+	cmd.Flags().String(flagDescription, "", "Description of the event type")
+	cmd.Flags().String(flagSchema, "", "JSON Schema of the event data, inline or as @file")
+	cmd.Flags().Bool(flagForce, false, "Apply breaking schema changes")
+
 	return cmd
 }
 
 func NewCmdEventTypeGet(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "get",
-		Short: "Retrieve an event type type",
+		Use:   "get <event-type>",
+		Short: "Retrieve an event type by ID or name",
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
 			xibugo event-type get 123
-			xibugo event-type get 123
+			xibugo event-type get order.created -o json
 		`),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
@@ -149,11 +330,64 @@ func NewCmdEventTypeGet(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			eventType, err := client.GetEventType(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			return printEventType(cmd, eventType)
 		},
 	}
 
 	return cmd
 }
+
+func printEventType(cmd *cobra.Command, eventType *api.EventType) error {
+	if ok, err := printStructured(cmd.OutOrStdout(), eventType); ok {
+		return err
+	}
+
+	cmd.Printf("ID:          %s\n", eventType.ID)
+	cmd.Printf("Name:        %s\n", eventType.Name)
+	cmd.Printf("Description: %s\n", eventType.Description)
+	cmd.Printf("Created:     %s\n", eventType.CreatedAt.Local().Format(time.RFC3339))
+	cmd.Printf("Updated:     %s\n", eventType.UpdatedAt.Local().Format(time.RFC3339))
+
+	if eventType.Version > 0 {
+		cmd.Printf("Version:     %d\n", eventType.Version)
+	}
+
+	if hasSchema(eventType.Schema) {
+		cmd.Printf("Schema:      %s\n", eventType.Schema)
+	}
+
+	return nil
+}
+
+func hasSchema(schema json.RawMessage) bool {
+	return len(schema) > 0 && string(schema) != "null"
+}
+
+// readSchema reads a JSON Schema given inline or as @file and compiles it.
+func readSchema(cmd *cobra.Command, value string) (json.RawMessage, *schema.Schema, error) {
+	raw, err := readData(value, cmd.InOrStdin())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !json.Valid(raw) {
+		return nil, nil, &UsageError{Err: errors.New("schema is not valid JSON")}
+	}
+
+	s, err := schema.Compile(raw)
+	if err != nil {
+		return nil, nil, &UsageError{Err: err}
+	}
+
+	return raw, s, nil
+}
@@ -0,0 +1,214 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagNoValidate = "no-validate"
+
+type validateResult struct {
+	Valid  bool           `json:"valid"`
+	Type   string         `json:"type"`
+	Schema bool           `json:"schema"`
+	Errors []schema.Error `json:"errors,omitempty"`
+}
+
+func NewCmdEventValidate(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "validate",
+		Short: "Validate event data against the schema of its type",
+		Long: heredoc.Doc(`
+			Validate event data against the JSON Schema of its event type without
+			publishing it. Exits with code 6 when the data does not match, listing
+			each violation with the JSON pointer of the offending value.
+
+			Schemas are cached for a few minutes, so producer test suites can call
+			this repeatedly without fetching them each time.
+		`),
+		Example: heredoc.Doc(`
+			xibugo event validate --type order.created --data @order.json
+			xibugo event validate --type order.created --data '{"id":"ord_1"}' -o json
+		`),
+		Args: cobra.NoArgs,
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			data, err := readPayload(cmd)
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			eventType := viper.GetString(flagType)
+
+			s, err := eventSchema(cmd.Context(), cfg, client, eventType)
+			if err != nil {
+				return err
+			}
+
+			result := validateResult{Valid: true, Type: eventType, Schema: s != nil}
+
+			var validationErr *schema.ValidationError
+
+			if s != nil {
+				err = s.Validate(data)
+				if errors.As(err, &validationErr) {
+					result.Valid, result.Errors = false, validationErr.Errors
+				} else if err != nil {
+					return err
+				}
+			}
+
+			ok, err := printStructured(cmd.OutOrStdout(), result)
+			if err != nil {
+				return err
+			}
+
+			if !result.Valid {
+				return validationErr
+			}
+
+			if ok {
+				return nil
+			}
+
+			if s == nil {
+				cmd.Printf("Event type %s has no schema, any data is accepted\n", eventType)
+				return nil
+			}
+
+			cmd.Printf("Data is valid for %s\n", eventType)
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagType, "", "Type of the event")
+	cmd.Flags().String(flagData, "", "Event data as JSON, inline, as @file or @- for standard input")
+	_ = cmd.MarkFlagRequired(flagType)
+	_ = cmd.MarkFlagRequired(flagData)
+
+	return cmd
+}
+
+// readPayload reads --data and checks it is JSON.
+func readPayload(cmd *cobra.Command) (json.RawMessage, error) {
+	data, err := readData(viper.GetString(flagData), cmd.InOrStdin())
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(data) {
+		return nil, &UsageError{Err: errors.New("data is not valid JSON")}
+	}
+
+	return data, nil
+}
+
+// validatePayload checks data against the schema of its event type, if it
+// has one.
+func validatePayload(ctx context.Context, cfg *config.Config, client *api.Client, eventType string, data []byte) error {
+	s, err := eventSchema(ctx, cfg, client, eventType)
+	if err != nil || s == nil {
+		return err
+	}
+
+	return s.Validate(data)
+}
+
+// eventSchema returns the compiled schema of an event type, or nil when it
+// has none or is not registered. Schemas are cached per endpoint and account.
+func eventSchema(ctx context.Context, cfg *config.Config, client *api.Client, eventType string) (*schema.Schema, error) {
+	cache := schemaCache()
+	key := schemaCacheKey(cfg, eventType)
+
+	raw, ok := cache.Get(key)
+	if !ok {
+		// Events of unregistered types are accepted, without a schema.
+		et, err := client.GetEventType(ctx, eventType)
+		if err != nil && !errors.Is(err, api.ErrNotFound) {
+			return nil, err
+		}
+
+		raw = nil
+		if et != nil && hasSchema(et.Schema) {
+			raw = et.Schema
+		}
+
+		// A cache that cannot be written only costs a fetch next time.
+		_ = cache.Put(key, raw)
+	}
+
+	if raw == nil {
+		return nil, nil
+	}
+
+	return schema.Compile(raw)
+}
+
+func schemaCache() *schema.Cache {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	return schema.NewCache(filepath.Join(dir, "xibugo", "schemas"))
+}
+
+// forgetSchema drops the cached schema of an event type after it was
+// created or changed, so that events are validated against the new one.
+func forgetSchema(cfg *config.Config, eventType string) {
+	// A stale entry that cannot be removed expires with the TTL.
+	_ = schemaCache().Delete(schemaCacheKey(cfg, eventType))
+}
+
+func schemaCacheKey(cfg *config.Config, eventType string) string {
+	sum := sha256.Sum256([]byte(cfg.Endpoint() + "\x00" + cfg.Account))
+
+	return hex.EncodeToString(sum[:8]) + "/" + url.PathEscape(eventType)
+}
@@ -0,0 +1,60 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+func TestEventSchemaUnregisteredType(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	client, cfg := newMockAPI(t)
+	ctx := context.Background()
+
+	s, err := eventSchema(ctx, cfg, client, "order.created")
+	if err != nil || s != nil {
+		t.Fatalf("eventSchema() = %v, %v, want no schema for an unregistered type", s, err)
+	}
+
+	if _, ok := schemaCache().Get(schemaCacheKey(cfg, "order.created")); !ok {
+		t.Fatal("the absence of a schema was not cached")
+	}
+
+	_, err = client.CreateEventType(ctx, api.EventTypeParams{
+		Name:   "order.created",
+		Schema: json.RawMessage(`{"type":"object","required":["id"]}`),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forgetSchema(cfg, "order.created")
+
+	s, err = eventSchema(ctx, cfg, client, "order.created")
+	if err != nil || s == nil {
+		t.Fatalf("eventSchema() = %v, %v, want the schema of the new type", s, err)
+	}
+
+	if err := s.Validate([]byte(`{}`)); err == nil {
+		t.Fatal("data missing a required property was accepted")
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/manifest"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+	"github.com/spf13/cobra"
+)
+
+// Exit codes returned by the CLI. They are part of the public interface, see
+// docs/exit-codes.md, and must not be renumbered.
+const (
+	ExitOK          = 0
+	ExitError       = 1
+	ExitUsage       = 2
+	ExitAuth        = 3
+	ExitNotFound    = 4
+	ExitConflict    = 5
+	ExitValidation  = 6
+	ExitRateLimited = 7
+	ExitServer      = 8
+	ExitNetwork     = 9
+	ExitDrift       = 10
+	ExitBreaking    = 11
+)
+
+// UsageError is returned when a command is invoked with invalid arguments or
+// flags.
+type UsageError struct {
+	Err error
+}
+
+func (e *UsageError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UsageError) Unwrap() error {
+	return e.Err
+}
+
+// DriftError is returned when the account differs from a manifest.
+type DriftError struct {
+	Changes int
+}
+
+func (e *DriftError) Error() string {
+	return fmt.Sprintf("the account has drifted from the manifest (changes: %d)", e.Changes)
+}
+
+// BreakingChangeError is returned when a schema change would break consumers
+// or producers of an event type.
+type BreakingChangeError struct {
+	Changes int
+}
+
+func (e *BreakingChangeError) Error() string {
+	return fmt.Sprintf("the schema change is breaking (breaking changes: %d)", e.Changes)
+}
+
+// ExitCode maps an error returned by a command to the process exit code.
+func ExitCode(err error) int {
+	var (
+		usageErr    *UsageError
+		driftErr    *DriftError
+		breakingErr *BreakingChangeError
+		planErr     *manifest.BreakingSchemaError
+		schemaErr   *schema.ValidationError
+	)
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.As(err, &usageErr), isUnknownCommand(err):
+		return ExitUsage
+	case errors.As(err, &driftErr):
+		return ExitDrift
+	case errors.As(err, &breakingErr), errors.As(err, &planErr):
+		return ExitBreaking
+	case errors.Is(err, api.ErrUnauthorized):
+		return ExitAuth
+	case errors.Is(err, api.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, api.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, api.ErrValidation), errors.As(err, &schemaErr):
+		return ExitValidation
+	case errors.Is(err, api.ErrRateLimited):
+		return ExitRateLimited
+	case errors.Is(err, api.ErrServer):
+		return ExitServer
+	case errors.Is(err, api.ErrNetwork):
+		return ExitNetwork
+	}
+
+	return ExitError
+}
+
+// isUnknownCommand detects the error cobra returns for an unknown
+// subcommand, which is raised before any of our hooks run and is not typed.
+func isUnknownCommand(err error) bool {
+	return strings.HasPrefix(err.Error(), "unknown command ")
+}
+
+// usageArgs marks errors of every positional argument validator in the tree
+// as usage errors.
+func usageArgs(cmd *cobra.Command) {
+	if validate := cmd.Args; validate != nil {
+		cmd.Args = func(cmd *cobra.Command, args []string) error {
+			if err := validate(cmd, args); err != nil {
+				return &UsageError{Err: err}
+			}
+
+			return nil
+		}
+	}
+
+	for _, c := range cmd.Commands() {
+		usageArgs(c)
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/manifest"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const flagSecrets = "secrets"
+
+func NewCmdExport(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Write the event types, webhooks and subscriptions of the account as a manifest",
+		Long: heredoc.Doc(`
+			Write the event types, webhooks and subscriptions of the account as a
+			manifest that can be committed, diffed and given to apply.
+
+			Webhook secrets are left out by default. With --secrets env they are
+			replaced by environment variable references named after the webhook
+			URL, and with --secrets include they are written in plain text.
+		`),
+		Args: cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo export > xigubo.yaml
+			xibugo export --secrets env --env sandbox | xibugo apply -f - --env production
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			secrets := manifest.SecretMode(viper.GetString(flagSecrets))
+			if !validSecretMode(secrets) {
+				return &UsageError{Err: fmt.Errorf("invalid secrets mode %q, expected one of %v", secrets, manifest.SecretModes)}
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			state, err := manifest.Fetch(cmd.Context(), client)
+			if err != nil {
+				return err
+			}
+
+			m, err := manifest.FromState(state, secrets)
+			if err != nil {
+				return err
+			}
+
+			if outputFormat() == formatJSON {
+				_, err := printStructured(cmd.OutOrStdout(), m)
+				return err
+			}
+
+			return m.Encode(cmd.OutOrStdout())
+		},
+	}
+
+	cmd.Flags().String(flagSecrets, string(manifest.SecretsOmit), "How to write webhook secrets: omit, env or include")
+
+	return cmd
+}
+
+func validSecretMode(mode manifest.SecretMode) bool {
+	for _, m := range manifest.SecretModes {
+		if m == mode {
+			return true
+		}
+	}
+
+	return false
+}
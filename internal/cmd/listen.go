@@ -0,0 +1,250 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagForwardTo = "forward-to"
+	flagEvents    = "events"
+
+	listenReconnectDelay    = time.Second
+	listenMaxReconnectDelay = 30 * time.Second
+	forwardTimeout          = 30 * time.Second
+)
+
+func NewCmdListen(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "listen",
+		Short: "Forward webhook deliveries to a local endpoint",
+		Args:  cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo listen --forward-to http://localhost:8080/hook
+			xibugo listen --events order.created,order.paid --forward-to http://localhost:8080/hook
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			forwardTo, err := parseForwardURL(viper.GetString(flagForwardTo))
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+			defer stop()
+
+			f := &forwarder{
+				url:    forwardTo,
+				client: &http.Client{Timeout: forwardTimeout},
+				out:    cmd.OutOrStdout(),
+			}
+
+			listenOpts := api.ListenOptions{
+				EventTypes: viper.GetStringSlice(flagEvents),
+			}
+
+			cmd.PrintErrf("Forwarding deliveries to %s (press Ctrl+C to quit)\n", f.url)
+
+			return listen(ctx, cmd, client, listenOpts, func(d *api.Delivery) error {
+				return f.forward(ctx, d)
+			})
+		},
+	}
+
+	cmd.Flags().String(flagForwardTo, "", "URL deliveries are forwarded to")
+	cmd.Flags().StringSlice(flagEvents, nil, "Only forward deliveries of these event types")
+
+	if err := cmd.MarkFlagRequired(flagForwardTo); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+// listen keeps a delivery stream open, reconnecting with a growing delay
+// when the connection drops, until ctx is done or the API rejects the
+// stream.
+func listen(ctx context.Context, cmd *cobra.Command, client *api.Client, opts api.ListenOptions, fn func(*api.Delivery) error) error {
+	delay := listenReconnectDelay
+
+	for {
+		lastID, err := client.Listen(ctx, opts, func(d *api.Delivery) error {
+			delay = listenReconnectDelay
+			return fn(d)
+		})
+
+		opts.LastDeliveryID = lastID
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil && !reconnectable(err) {
+			return err
+		}
+
+		if err != nil {
+			cmd.PrintErrf("Connection lost: %v\n", err)
+		}
+
+		cmd.PrintErrf("Reconnecting in %s\n", delay)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > listenMaxReconnectDelay {
+			delay = listenMaxReconnectDelay
+		}
+	}
+}
+
+// parseForwardURL checks the URL deliveries are forwarded to, so that a
+// typo is reported once rather than on every delivery.
+func parseForwardURL(value string) (string, error) {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "", &UsageError{Err: fmt.Errorf("invalid --forward-to %q, expected an http or https URL", value)}
+	}
+
+	return u.String(), nil
+}
+
+func reconnectable(err error) bool {
+	return errors.Is(err, api.ErrNetwork) ||
+		errors.Is(err, api.ErrServer) ||
+		errors.Is(err, api.ErrRateLimited)
+}
+
+type forwarder struct {
+	url    string
+	client *http.Client
+	out    io.Writer
+}
+
+type forwardResult struct {
+	DeliveryID string `json:"delivery_id"`
+	EventID    string `json:"event_id"`
+	EventType  string `json:"event_type"`
+	Status     int    `json:"status,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// forward posts a delivery to the local endpoint and reports the outcome.
+// Failing to reach the endpoint is reported but does not stop listening.
+func (f *forwarder) forward(ctx context.Context, d *api.Delivery) error {
+	result := forwardResult{
+		DeliveryID: d.ID,
+		EventID:    d.EventID,
+		EventType:  d.EventType,
+	}
+
+	start := time.Now()
+	status, err := f.post(ctx, d)
+	result.DurationMS = time.Since(start).Milliseconds()
+	result.Status = status
+
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	if outputFormat() == formatJSON {
+		return json.NewEncoder(f.out).Encode(result)
+	}
+
+	outcome := fmt.Sprintf("[%d %s]", status, http.StatusText(status))
+	if err != nil {
+		outcome = fmt.Sprintf("[error: %v]", err)
+	}
+
+	_, err = fmt.Fprintf(
+		f.out,
+		"%s  %-24s %s  -> POST %s %s %dms\n",
+		time.Now().Format("2006-01-02 15:04:05"),
+		d.EventType,
+		d.EventID,
+		f.url,
+		outcome,
+		result.DurationMS,
+	)
+
+	return err
+}
+
+func (f *forwarder) post(ctx context.Context, d *api.Delivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	for name, value := range d.Headers {
+		req.Header.Set(name, value)
+	}
+
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,391 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/delivery"
+	"github.com/spf13/cobra"
+)
+
+const listenTestTimeout = 10 * time.Second
+
+// forwarded is a delivery received by the local endpoint.
+type forwarded struct {
+	EventID string
+	Header  http.Header
+	Payload delivery.Payload
+}
+
+// streamServer stands in for the delivery stream of the API. It keeps every
+// delivery published so that listeners can resume with Last-Event-ID.
+type streamServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	deliveries []api.Delivery
+	listeners  map[chan api.Delivery]struct{}
+	seq        int
+}
+
+// newStreamAPI starts the stand-in API and returns a client for it.
+func newStreamAPI(t *testing.T) (*streamServer, *api.Client) {
+	t.Helper()
+
+	s := &streamServer{listeners: map[chan api.Delivery]struct{}{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveStream))
+	t.Cleanup(s.Close)
+
+	client, err := api.NewClient(&config.Config{Account: "acc_1", AccessToken: "t", BaseURL: s.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s, client
+}
+
+func (s *streamServer) serveStream(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/"+api.Version+"/accounts/acc_1/deliveries/stream" {
+		http.NotFound(w, r)
+		return
+	}
+
+	var types []string
+	if value := r.URL.Query().Get("types"); value != "" {
+		types = strings.Split(value, ",")
+	}
+
+	ch := make(chan api.Delivery, 16)
+
+	s.mu.Lock()
+	missed := s.after(r.Header.Get("Last-Event-ID"))
+	s.listeners[ch] = struct{}{}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.listeners, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.WriteHeader(http.StatusOK)
+	w.(http.Flusher).Flush()
+
+	send := func(d api.Delivery) {
+		if !matchAny(types, d.EventType) {
+			return
+		}
+
+		data, _ := json.Marshal(d)
+		fmt.Fprintf(w, "id: %s\nevent: delivery\ndata: %s\n\n", d.ID, data)
+		w.(http.Flusher).Flush()
+	}
+
+	for _, d := range missed {
+		send(d)
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case d := <-ch:
+			send(d)
+		}
+	}
+}
+
+// after returns the deliveries published after lastID. It must be called
+// with the lock held.
+func (s *streamServer) after(lastID string) []api.Delivery {
+	if lastID == "" {
+		return nil
+	}
+
+	for i, d := range s.deliveries {
+		if d.ID == lastID {
+			return append([]api.Delivery(nil), s.deliveries[i+1:]...)
+		}
+	}
+
+	return nil
+}
+
+func matchAny(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, eventType); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// publish relays a delivery of a new event to the listeners and returns the
+// ID of the event.
+func (s *streamServer) publish(t *testing.T, eventType string) string {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.seq++
+	eventID := fmt.Sprintf("evt_%d", s.seq)
+
+	payload, err := json.Marshal(delivery.Payload{ID: eventID, Type: eventType, Data: json.RawMessage(`{"id":"ord_1"}`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	d := api.Delivery{
+		ID:        fmt.Sprintf("dlv_%d", s.seq),
+		EventID:   eventID,
+		EventType: eventType,
+		Headers: map[string]string{
+			"Content-Type":            "application/json",
+			delivery.HeaderDeliveryID: fmt.Sprintf("dlv_%d", s.seq),
+			delivery.HeaderEventID:    eventID,
+			delivery.HeaderEventType:  eventType,
+		},
+		Payload: payload,
+	}
+
+	s.deliveries = append(s.deliveries, d)
+
+	for ch := range s.listeners {
+		ch <- d
+	}
+
+	return eventID
+}
+
+// waitListeners waits until n listeners are connected to the stream.
+func (s *streamServer) waitListeners(t *testing.T, n int) {
+	t.Helper()
+
+	deadline := time.Now().Add(listenTestTimeout)
+
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		connected := len(s.listeners)
+		s.mu.Unlock()
+
+		if connected == n {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("want %d listeners on the delivery stream", n)
+}
+
+// newEndpoint starts a local endpoint that reports the deliveries it
+// receives.
+func newEndpoint(t *testing.T) (*httptest.Server, <-chan forwarded) {
+	t.Helper()
+
+	received := make(chan forwarded, 16)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var f forwarded
+		if err := json.NewDecoder(r.Body).Decode(&f.Payload); err != nil {
+			t.Errorf("decoding forwarded payload: %v", err)
+		}
+
+		f.EventID = r.Header.Get(delivery.HeaderEventID)
+		f.Header = r.Header
+		received <- f
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, received
+}
+
+// startListening runs listen in the background, forwarding to endpoint,
+// until the test ends.
+func startListening(t *testing.T, client *api.Client, endpoint string, opts api.ListenOptions) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	cmd := &cobra.Command{}
+	cmd.SetErr(io.Discard)
+
+	f := &forwarder{url: endpoint, client: &http.Client{Timeout: forwardTimeout}, out: io.Discard}
+
+	go func() {
+		defer close(done)
+
+		if err := listen(ctx, cmd, client, opts, func(d *api.Delivery) error {
+			return f.forward(ctx, d)
+		}); err != nil {
+			t.Errorf("listen: %v", err)
+		}
+	}()
+}
+
+func expectForwarded(t *testing.T, received <-chan forwarded, eventID string) forwarded {
+	t.Helper()
+
+	select {
+	case f := <-received:
+		if f.EventID != eventID {
+			t.Fatalf("forwarded event %s, want %s", f.EventID, eventID)
+		}
+
+		return f
+	case <-time.After(listenTestTimeout):
+		t.Fatalf("event %s was not forwarded", eventID)
+	}
+
+	return forwarded{}
+}
+
+func expectNothingForwarded(t *testing.T, received <-chan forwarded) {
+	t.Helper()
+
+	select {
+	case f := <-received:
+		t.Fatalf("unexpected delivery of event %s", f.EventID)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestListenForwardsDeliveries(t *testing.T) {
+	stream, client := newStreamAPI(t)
+	endpoint, received := newEndpoint(t)
+
+	startListening(t, client, endpoint.URL, api.ListenOptions{EventTypes: []string{"order.*"}})
+	stream.waitListeners(t, 1)
+
+	id := stream.publish(t, "order.created")
+	f := expectForwarded(t, received, id)
+
+	if f.Payload.ID != id || f.Payload.Type != "order.created" || string(f.Payload.Data) != `{"id":"ord_1"}` {
+		t.Errorf("unexpected payload %+v", f.Payload)
+	}
+
+	if got := f.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("got Content-Type %q, want application/json", got)
+	}
+
+	stream.publish(t, "customer.created")
+	expectNothingForwarded(t, received)
+}
+
+func TestListenReconnectsAndResumes(t *testing.T) {
+	stream, client := newStreamAPI(t)
+	endpoint, received := newEndpoint(t)
+
+	startListening(t, client, endpoint.URL, api.ListenOptions{})
+	stream.waitListeners(t, 1)
+
+	before := stream.publish(t, "order.created")
+	expectForwarded(t, received, before)
+
+	// Drop the stream, then publish while the listener is reconnecting: the
+	// event is replayed once it resumes after the last delivery it handled.
+	stream.CloseClientConnections()
+
+	missed := stream.publish(t, "order.paid")
+	expectForwarded(t, received, missed)
+
+	after := stream.publish(t, "order.shipped")
+	expectForwarded(t, received, after)
+	expectNothingForwarded(t, received)
+}
+
+func TestListenResumesAfterLastDelivery(t *testing.T) {
+	stream, client := newStreamAPI(t)
+
+	first := stream.publish(t, "order.created")
+	next := stream.publish(t, "order.paid")
+
+	ctx, cancel := context.WithTimeout(context.Background(), listenTestTimeout)
+	defer cancel()
+
+	var got []string
+
+	_, err := client.Listen(ctx, api.ListenOptions{LastDeliveryID: "dlv_1"}, func(d *api.Delivery) error {
+		got = append(got, d.EventID)
+		if d.EventID == next {
+			cancel()
+		}
+
+		return nil
+	})
+	if err != context.Canceled {
+		t.Fatalf("got %v, want the stream to be cancelled", err)
+	}
+
+	if len(got) != 1 || got[0] != next {
+		t.Fatalf("resumed stream delivered %v, want only %s after %s", got, next, first)
+	}
+}
+
+func TestParseForwardURL(t *testing.T) {
+	tests := []struct {
+		value string
+		ok    bool
+	}{
+		{value: "http://localhost:8080/hook", ok: true},
+		{value: "https://example.com/hook", ok: true},
+		{value: "localhost:8080/hook"},
+		{value: "ftp://example.com/hook"},
+		{value: "http://"},
+		{value: "http://local host/"},
+		{value: ""},
+	}
+
+	for _, tt := range tests {
+		_, err := parseForwardURL(tt.value)
+		if (err == nil) != tt.ok {
+			t.Errorf("parseForwardURL(%q) = %v, want ok %v", tt.value, err, tt.ok)
+		}
+
+		if err != nil && ExitCode(err) != ExitUsage {
+			t.Errorf("parseForwardURL(%q) exit code %d, want %d", tt.value, ExitCode(err), ExitUsage)
+		}
+	}
+}
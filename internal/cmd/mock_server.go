@@ -0,0 +1,111 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/mock"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagDataFile    = "data-file"
+	flagRetryDelays = "retry-delays"
+
+	defaultMockPort = 12111
+)
+
+func NewCmdMockServer(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "mock-server",
+		Short: "Run an offline mock of the Xigubo API",
+		Long: heredoc.Doc(`
+			Run an in-memory implementation of the event type, webhook,
+			subscription and event endpoints. Events published to it are
+			delivered, signed, to the subscribed webhooks and retried on
+			failure, as the real API does. Each account ID gets its own
+			resources, so commands working across accounts such as copy can be
+			tried against it.
+
+			The base URL is printed on standard output so it can be passed to
+			other commands with --base-url.
+		`),
+		Args: cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo mock-server --port 12111 --data-file mock.json
+			xibugo --base-url http://127.0.0.1:12111 whoami
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			retryDelays, err := cmd.Flags().GetDurationSlice(flagRetryDelays)
+			if err != nil {
+				return err
+			}
+
+			handler, err := mock.New(mock.Options{
+				DataFile:    viper.GetString(flagDataFile),
+				RetryDelays: retryDelays,
+				Log:         cmd.ErrOrStderr(),
+			})
+			if err != nil {
+				return err
+			}
+
+			defer handler.Close()
+
+			addr := net.JoinHostPort(viper.GetString(flagHost), strconv.Itoa(viper.GetInt(flagPort)))
+
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+
+			cmd.Println(fmt.Sprintf("http://%s", ln.Addr()))
+			cmd.PrintErrln("Mock API running (press Ctrl+C to quit)")
+
+			srv := &http.Server{
+				Handler:           handler,
+				ReadHeaderTimeout: readHeaderTimeout,
+			}
+
+			// Ending the delivery streams lets the shutdown complete.
+			srv.RegisterOnShutdown(handler.Close)
+
+			return serve(cmd.Context(), srv, ln)
+		},
+	}
+
+	cmd.Flags().String(flagHost, defaultServeHost, "Address to listen on")
+	cmd.Flags().Int(flagPort, defaultMockPort, "Port to listen on, 0 picks a free one")
+	cmd.Flags().String(flagDataFile, "", "JSON file the state is loaded from and saved to")
+	cmd.Flags().DurationSlice(flagRetryDelays, mock.DefaultRetryDelays, "Delays between delivery attempts")
+
+	return cmd
+}
@@ -0,0 +1,137 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/mattn/go-isatty"
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+	ansiGray   = "\x1b[90m"
+)
+
+var outputFormats = map[string]struct{}{
+	formatText:  {},
+	formatTable: {},
+	formatJSON:  {},
+	formatYAML:  {},
+}
+
+func validateOutputFormat() error {
+	if _, ok := outputFormats[outputFormat()]; !ok {
+		return &UsageError{Err: fmt.Errorf("invalid output format %q", outputFormat())}
+	}
+
+	return nil
+}
+
+func outputFormat() string {
+	return viper.GetString(flagOutput)
+}
+
+// printStructured writes v as JSON or YAML when one of those formats was
+// selected and reports whether it did so.
+func printStructured(w io.Writer, v interface{}) (bool, error) {
+	switch outputFormat() {
+	case formatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+
+		return true, enc.Encode(v)
+	case formatYAML:
+		// Round-trip through JSON so the API models' json tags also
+		// name the YAML keys.
+		data, err := json.Marshal(v)
+		if err != nil {
+			return true, err
+		}
+
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			return true, err
+		}
+
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+
+		if err := enc.Encode(generic); err != nil {
+			return true, err
+		}
+
+		return true, enc.Close()
+	}
+
+	return false, nil
+}
+
+// colorEnabled reports whether w is a terminal that should get colored
+// output. Setting NO_COLOR disables colors.
+func colorEnabled(w io.Writer) bool {
+	if os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+
+	return ok && isatty.IsTerminal(f.Fd())
+}
+
+// colorStatus wraps an event or attempt status in the color matching its
+// outcome.
+func colorStatus(status string, enabled bool) string {
+	if !enabled {
+		return status
+	}
+
+	var color string
+
+	switch status {
+	case api.EventStatusDelivered, api.AttemptStatusSucceeded:
+		color = ansiGreen
+	case api.EventStatusFailed:
+		color = ansiRed
+	case api.EventStatusPending, api.EventStatusScheduled:
+		color = ansiYellow
+	case api.EventStatusCancelled:
+		color = ansiGray
+	default:
+		return status
+	}
+
+	return color + status + ansiReset
+}
+
+// plural returns n followed by noun, in the plural unless n is 1.
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+
+	return fmt.Sprintf("%d %ss", n, noun)
+}
@@ -20,8 +20,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -36,17 +39,42 @@ const (
 	flagAccessToken         = "access-token"
 	flagBaseURL             = "base-url"
 	flagSandbox             = "sandbox"
+	flagEnv                 = "env"
+	flagCAFile              = "ca-file"
+	flagEnvironments        = "environments"
 	flagProfile             = "profile"
 	flagConfig              = "config-file"
-	envPrefix               = "XIGUBO"
+	flagOutput              = "output"
+	flagVerbose             = "verbose"
+	flagDebug               = "debug"
+	flagRetries             = "retries"
+	flagTimeout             = "timeout"
+	defaultRetries          = 3
+	defaultTimeout          = 30 * time.Second
 	defaultProfile          = "default"
 	defaultConfigFileFormat = "yaml"
 )
 
 func NewCmdRoot(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:          "xibugo",
-		SilenceUsage: true,
+		Use:           "xibugo",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// cobra validates these after the pre-run hooks; doing it here
+			// first lets the failures be reported as usage errors.
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return &UsageError{Err: err}
+			}
+
+			if err := cmd.ValidateFlagGroups(); err != nil {
+				return &UsageError{Err: err}
+			}
+
+			selectEndpoint(cmd)
+
+			return validateOutputFormat()
+		},
 	}
 
 	cmd.AddCommand(NewCmdConfig(opts))
@@ -55,16 +83,39 @@ func NewCmdRoot(opts *internal.CommandOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdSubscription(opts))
 	cmd.AddCommand(NewCmdEvent(opts))
 	cmd.AddCommand(NewCmdEventType(opts))
+	cmd.AddCommand(NewCmdApply(opts))
+	cmd.AddCommand(NewCmdExport(opts))
+	cmd.AddCommand(NewCmdDiff(opts))
+	cmd.AddCommand(NewCmdCopy(opts))
+	cmd.AddCommand(NewCmdListen(opts))
+	cmd.AddCommand(NewCmdMockServer(opts))
 	cmd.AddCommand(NewCmdVersion(opts))
 
 	cobra.OnInitialize(lookupConfigFiles)
 
+	cmd.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &UsageError{Err: err}
+	})
+	usageArgs(cmd)
+
 	cmd.PersistentFlags().String(flagBaseURL, "", "Base URL")
 	cmd.PersistentFlags().String(flagAccessToken, "", "Access token")
+	cmd.PersistentFlags().String(flagEnv, "", "Environment")
+	cmd.PersistentFlags().Bool(flagSandbox, false, "Use the sandbox environment")
 	cmd.PersistentFlags().StringVarP(&configFile, flagConfig, "c", "", "Configuration file")
 	cmd.PersistentFlags().StringVar(&profile, flagProfile, "default", "Profile")
-
-	viper.SetEnvPrefix(envPrefix)
+	cmd.PersistentFlags().StringP(flagOutput, "o", formatText, "Output format: text, table, json or yaml")
+	cmd.PersistentFlags().Bool(flagVerbose, false, "Log HTTP requests to stderr")
+	cmd.PersistentFlags().Bool(flagDebug, false, "Dump HTTP requests and responses to stderr")
+	cmd.PersistentFlags().Int(flagRetries, defaultRetries, "Maximum number of retries of transient failures")
+	cmd.PersistentFlags().Duration(flagTimeout, defaultTimeout, "Maximum time a request may take, retries included")
+
+	// Each of these selects the API endpoint, so asking for more than one at
+	// once is ambiguous. The one given overrides the others when they come
+	// from the configuration file or the environment, see selectEndpoint.
+	cmd.MarkFlagsMutuallyExclusive(flagBaseURL, flagEnv, flagSandbox)
+
+	config.BindEnv(viper.GetViper())
 	if err := viper.BindPFlags(cmd.PersistentFlags()); err != nil {
 		panic(err)
 	}
@@ -72,22 +123,43 @@ func NewCmdRoot(opts *internal.CommandOptions) *cobra.Command {
 	return cmd
 }
 
-func lookupConfigFiles() {
-	var err error
+// selectEndpoint makes an endpoint chosen on the command line override the
+// others set in the configuration file or environment variables. Otherwise
+// the settings are resolved by kind, see config.New, so that a base URL in
+// the configuration file would silently beat --sandbox, and settings the
+// flag overrides would be reported as conflicting.
+func selectEndpoint(cmd *cobra.Command) {
+	flags := cmd.Flags()
+
+	if flags.Changed(flagBaseURL) {
+		viper.Set(flagEnv, "")
+		viper.Set(flagSandbox, false)
+	}
 
+	if flags.Changed(flagEnv) {
+		viper.Set(flagBaseURL, "")
+		viper.Set(flagSandbox, false)
+	}
+
+	if sandbox, _ := flags.GetBool(flagSandbox); sandbox && flags.Changed(flagSandbox) {
+		viper.Set(flagBaseURL, "")
+		viper.Set(flagEnv, "")
+	}
+}
+
+func lookupConfigFiles() {
 	if configFile != "" {
 		viper.SetConfigFile(configFile)
 	} else if configFile := os.Getenv("XIGUBO_CONFIG_FILE"); configFile != "" {
 		viper.SetConfigFile(configFile)
 	} else {
-		configHome := os.Getenv("XDG_CONFIG_HOME")
-		if configHome == "" {
-			configHome, err = os.UserConfigDir()
-			cobra.CheckErr(err)
+		dirs, err := configDirs()
+		cobra.CheckErr(err)
+
+		for _, dir := range dirs {
+			viper.AddConfigPath(dir)
 		}
 
-		viper.AddConfigPath(filepath.Join(configHome, "xibugo"))
-		viper.AddConfigPath("/etc/xibugo")
 		viper.SetConfigType(defaultConfigFileFormat)
 
 		if profile != "" {
@@ -99,11 +171,54 @@ func lookupConfigFiles() {
 		}
 	}
 
-	viper.AutomaticEnv()
-
 	if err := viper.ReadInConfig(); err != nil {
 		if _, ok := err.(viper.ConfigFileNotFoundError); !ok {
 			fmt.Println("Found error: ", err.Error())
 		}
 	}
 }
+
+// configDirs returns the directories profiles are looked up in, in order.
+func configDirs() ([]string, error) {
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		var err error
+		if configHome, err = os.UserConfigDir(); err != nil {
+			return nil, err
+		}
+	}
+
+	return []string{filepath.Join(configHome, "xibugo"), "/etc/xibugo"}, nil
+}
+
+// loadProfile reads the configuration of a named profile, independently of
+// the active one and of the flags given on the command line.
+func loadProfile(name string) (*config.Config, error) {
+	dirs, err := configDirs()
+	if err != nil {
+		return nil, err
+	}
+
+	v := viper.New()
+	v.SetConfigName(name)
+	v.SetConfigType(defaultConfigFileFormat)
+
+	for _, dir := range dirs {
+		v.AddConfigPath(dir)
+	}
+
+	if err := v.ReadInConfig(); err != nil {
+		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
+			return nil, fmt.Errorf("profile %q not found in %s", name, strings.Join(dirs, ", "))
+		}
+
+		return nil, fmt.Errorf("reading profile %q: %w", name, err)
+	}
+
+	cfg, err := config.NewFromViper(v)
+	if err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+
+	return cfg, nil
+}
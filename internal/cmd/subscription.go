@@ -17,8 +17,13 @@
 package cmd
 
 import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
 	"github.com/getumbeluzi/xibugo-cli/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -43,8 +48,10 @@ func NewCmdSubscriptionList(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List subscriptions",
+		Args:  cobra.NoArgs,
 		Example: heredoc.Doc(`
 			xibugo subscription list
+			xibugo subscription list --webhook 123
 		`),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
@@ -59,20 +66,49 @@ func NewCmdSubscriptionList(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			subscriptions, _, err := client.ListSubscriptions(cmd.Context(), api.SubscriptionListOptions{
+				ListOptions: api.ListOptions{
+					Page:    viper.GetInt(flagPage),
+					PerPage: viper.GetInt(flagPerPage),
+				},
+				WebhookID: viper.GetString(flagWebhook),
+			})
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), subscriptions); ok {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tWEBHOOK\tEVENT TYPE\tCREATED")
+
+			for _, sub := range subscriptions {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", sub.ID, sub.WebhookID, sub.EventType, sub.CreatedAt.Local().Format(time.RFC3339))
+			}
+
+			return w.Flush()
 		},
 	}
 
+	cmd.Flags().String(flagWebhook, "", "Only list subscriptions of this webhook")
+	cmd.Flags().Int(flagPage, 1, "Page to list")
+	cmd.Flags().Int(flagPerPage, api.DefaultPerPage, "Number of subscriptions per page")
+
 	return cmd
 }
 
 func NewCmdSubscriptionDelete(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "delete",
+		Use:   "delete <subscription-id>",
 		Short: "Delete a subscription",
-		Args:  cobra.NoArgs,
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
 			xibugo subscription delete 123
 		`),
@@ -89,7 +125,16 @@ func NewCmdSubscriptionDelete(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteSubscription(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+
+			cmd.Printf("Deleted subscription %s\n", args[0])
 
 			return nil
 		},
@@ -103,9 +148,10 @@ func NewCmdSubscriptionCreate(opts *internal.CommandOptions) *cobra.Command {
 		Use:   "create",
 		Short: "Create a subscription",
 		Example: heredoc.Doc(`
-			xibugo subscription create --webhook 123
+			xibugo subscription create --webhook 123 --type order.created
+			xibugo subscription create --webhook 123 --type 'order.*'
 		`),
-		Args: cobra.MaximumNArgs(1),
+		Args: cobra.NoArgs,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
 				panic(err)
@@ -119,22 +165,39 @@ func NewCmdSubscriptionCreate(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			subscription, err := client.CreateSubscription(cmd.Context(), api.SubscriptionParams{
+				WebhookID: viper.GetString(flagWebhook),
+				EventType: viper.GetString(flagType),
+			})
+			if err != nil {
+				return err
+			}
+
+			return printSubscription(cmd, subscription)
 		},
 	}
 
+	cmd.Flags().String(flagWebhook, "", "Webhook to deliver events to")
+	cmd.Flags().String(flagType, "", "Event type to subscribe to, may be a pattern such as 'order.*'")
+	_ = cmd.MarkFlagRequired(flagWebhook)
+	_ = cmd.MarkFlagRequired(flagType)
+
 	return cmd
 }
 
 func NewCmdSubscriptionGet(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "get",
+		Use:   "get <subscription-id>",
 		Short: "Retrieve a subscription",
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
 			xibugo subscription get 123
-			xibugo subscription get 123
+			xibugo subscription get 123 -o json
 		`),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
@@ -149,11 +212,33 @@ func NewCmdSubscriptionGet(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			subscription, err := client.GetSubscription(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
 
-			return nil
+			return printSubscription(cmd, subscription)
 		},
 	}
 
 	return cmd
 }
+
+func printSubscription(cmd *cobra.Command, subscription *api.Subscription) error {
+	if ok, err := printStructured(cmd.OutOrStdout(), subscription); ok {
+		return err
+	}
+
+	cmd.Printf("ID:         %s\n", subscription.ID)
+	cmd.Printf("Webhook:    %s\n", subscription.WebhookID)
+	cmd.Printf("Event type: %s\n", subscription.EventType)
+	cmd.Printf("Created:    %s\n", subscription.CreatedAt.Local().Format(time.RFC3339))
+	cmd.Printf("Updated:    %s\n", subscription.UpdatedAt.Local().Format(time.RFC3339))
+
+	return nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/getumbeluzi/xibugo-cli/internal"
 	"github.com/getumbeluzi/xibugo-cli/internal/build"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
 	"github.com/spf13/cobra"
 )
 
@@ -32,6 +33,11 @@ func NewCmdVersion(opts *internal.CommandOptions) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			internal.SetupIO(cmd, opts)
 
+			cfg, err := config.NewWithValidation(false)
+			if err != nil {
+				return err
+			}
+
 			versionTemplate := heredoc.Doc(`
 				Xigubo CLI version:       %v
 				Xigubo API endpoint:      %v
@@ -39,7 +45,7 @@ func NewCmdVersion(opts *internal.CommandOptions) *cobra.Command {
 				OS/Arch (client):           %v/%v
 			`)
 
-			cmd.Printf(versionTemplate, build.Version, prodBaseURL, "v2", runtime.GOOS, runtime.GOARCH)
+			cmd.Printf(versionTemplate, build.Version, cfg.Endpoint(), "v2", runtime.GOOS, runtime.GOARCH)
 
 			return nil
 		},
@@ -17,13 +17,25 @@
 package cmd
 
 import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
 	"github.com/MakeNowJust/heredoc/v2"
 	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
 	"github.com/getumbeluzi/xibugo-cli/internal/config"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+const (
+	flagURL         = "url"
+	flagDescription = "description"
+	flagDisabled    = "disabled"
+)
+
 const (
 	formatJSON  = "json"
 	formatYAML  = "yaml"
@@ -42,6 +54,12 @@ func NewCmdWebhook(opts *internal.CommandOptions) *cobra.Command {
 	cmd.AddCommand(NewCmdWebhookDelete(opts))
 	cmd.AddCommand(NewCmdWebhookCreate(opts))
 	cmd.AddCommand(NewCmdWebhookGet(opts))
+	cmd.AddCommand(NewCmdWebhookServe(opts))
+	cmd.AddCommand(NewCmdWebhookVerify(opts))
+	cmd.AddCommand(NewCmdWebhookSign(opts))
+	cmd.AddCommand(NewCmdWebhookSendTest(opts))
+	cmd.AddCommand(NewCmdWebhookAttempts(opts))
+	cmd.AddCommand(NewCmdWebhookStats(opts))
 
 	return cmd
 }
@@ -50,6 +68,7 @@ func NewCmdWebhookList(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List webhooks",
+		Args:  cobra.NoArgs,
 		Example: heredoc.Doc(`
 			xibugo webhook list
 		`),
@@ -66,20 +85,45 @@ func NewCmdWebhookList(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			webhooks, _, err := client.ListWebhooks(cmd.Context(), api.ListOptions{
+				Page:    viper.GetInt(flagPage),
+				PerPage: viper.GetInt(flagPerPage),
+			})
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), webhooks); ok {
+				return err
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ID\tURL\tDISABLED\tDESCRIPTION")
+
+			for _, wh := range webhooks {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", wh.ID, wh.URL, yesNo(wh.Disabled), wh.Description)
+			}
+
+			return w.Flush()
 		},
 	}
 
+	cmd.Flags().Int(flagPage, 1, "Page to list")
+	cmd.Flags().Int(flagPerPage, api.DefaultPerPage, "Number of webhooks per page")
+
 	return cmd
 }
 
 func NewCmdWebhookDelete(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "delete",
+		Use:   "delete <webhook-id>",
 		Short: "Delete a webhook",
-		Args:  cobra.NoArgs,
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
 			xibugo webhook delete 123
 		`),
@@ -96,7 +140,16 @@ func NewCmdWebhookDelete(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			if err := client.DeleteWebhook(cmd.Context(), args[0]); err != nil {
+				return err
+			}
+
+			cmd.Printf("Deleted webhook %s\n", args[0])
 
 			return nil
 		},
@@ -110,9 +163,10 @@ func NewCmdWebhookCreate(opts *internal.CommandOptions) *cobra.Command {
 		Use:   "create",
 		Short: "Create a webhook",
 		Example: heredoc.Doc(`
-			xibugo webhook create
+			xibugo webhook create --url https://example.com/hooks
+			xibugo webhook create --url https://example.com/hooks --description Orders --secret whsec_123
 		`),
-		Args: cobra.MaximumNArgs(1),
+		Args: cobra.NoArgs,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if err := viper.BindPFlags(cmd.Flags()); err != nil {
 				panic(err)
@@ -126,19 +180,39 @@ func NewCmdWebhookCreate(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			webhook, err := client.CreateWebhook(cmd.Context(), api.WebhookParams{
+				URL:         viper.GetString(flagURL),
+				Description: viper.GetString(flagDescription),
+				Secret:      viper.GetString(flagSecret),
+				Disabled:    viper.GetBool(flagDisabled),
+			})
+			if err != nil {
+				return err
+			}
+
+			return printWebhook(cmd, webhook)
 		},
 	}
 
+	cmd.Flags().String(flagURL, "", "URL events are delivered to")
+	cmd.Flags().String(flagDescription, "", "Description of the webhook")
+	cmd.Flags().String(flagSecret, "", "Secret deliveries are signed with, generated when empty")
+	cmd.Flags().Bool(flagDisabled, false, "Create the webhook disabled")
+	_ = cmd.MarkFlagRequired(flagURL)
+
 	return cmd
 }
 
 func NewCmdWebhookGet(opts *internal.CommandOptions) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "get",
+		Use:   "get <webhook-id>",
 		Short: "Retrieve a webhook",
+		Args:  cobra.ExactArgs(1),
 		Example: heredoc.Doc(`
 			xibugo webhook get 123
 		`),
@@ -155,11 +229,80 @@ func NewCmdWebhookGet(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
 
-			return nil
+			webhook, err := client.GetWebhook(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			return printWebhook(cmd, webhook)
 		},
 	}
 
 	return cmd
 }
+
+func NewCmdWebhookAttempts(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "attempts <webhook-id>",
+		Short: "List the delivery attempts made to a webhook",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			xibugo webhook attempts 123
+			xibugo webhook attempts 123 --failed-only
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			list := func(ctx context.Context, listOpts api.AttemptListOptions) ([]api.Attempt, *api.Pagination, error) {
+				return client.ListWebhookAttempts(ctx, args[0], listOpts)
+			}
+
+			attempts, err := collectAttempts(cmd.Context(), list, attemptListOptions(viper.GetBool(flagFailedOnly)))
+			if err != nil {
+				return err
+			}
+
+			return printAttempts(cmd, attempts, false)
+		},
+	}
+
+	cmd.Flags().Bool(flagFailedOnly, false, "Only list failed attempts")
+
+	return cmd
+}
+
+func printWebhook(cmd *cobra.Command, webhook *api.Webhook) error {
+	if ok, err := printStructured(cmd.OutOrStdout(), webhook); ok {
+		return err
+	}
+
+	cmd.Printf("ID:          %s\n", webhook.ID)
+	cmd.Printf("URL:         %s\n", webhook.URL)
+	cmd.Printf("Description: %s\n", webhook.Description)
+	cmd.Printf("Secret:      %s\n", webhook.Secret)
+	cmd.Printf("Disabled:    %s\n", yesNo(webhook.Disabled))
+	cmd.Printf("Created:     %s\n", webhook.CreatedAt.Local().Format(time.RFC3339))
+	cmd.Printf("Updated:     %s\n", webhook.UpdatedAt.Local().Format(time.RFC3339))
+
+	return nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/getumbeluzi/xibugo-cli/internal/delivery"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagType = "type"
+	flagData = "data"
+
+	responseSnippetSize = 512
+)
+
+type sendTestResult struct {
+	DeliveryID   string `json:"delivery_id"`
+	EventID      string `json:"event_id"`
+	EventType    string `json:"event_type"`
+	URL          string `json:"url"`
+	Status       int    `json:"status"`
+	DurationMS   int64  `json:"duration_ms"`
+	ResponseBody string `json:"response_body,omitempty"`
+}
+
+func NewCmdWebhookSendTest(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "send-test <webhook-id|url>",
+		Short: "Send a signed synthetic delivery to an endpoint",
+		Args:  cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			xibugo webhook send-test 123 --type order.created --data @order.json
+			xibugo webhook send-test http://localhost:8080/hook --secret whsec_... --type order.created
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			data, err := readData(viper.GetString(flagData), cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			if !json.Valid(data) {
+				return &UsageError{Err: errors.New("data is not valid JSON")}
+			}
+
+			d := &delivery.Delivery{
+				ID:     delivery.NewID("dlv_test"),
+				Secret: viper.GetString(flagSecret),
+				Payload: delivery.Payload{
+					ID:        delivery.NewID("evt_test"),
+					Type:      viper.GetString(flagType),
+					CreatedAt: time.Now().UTC(),
+					Data:      data,
+				},
+			}
+
+			if isURL(args[0]) {
+				d.URL = args[0]
+			} else {
+				cfg, err := config.New()
+				if err != nil {
+					return err
+				}
+
+				client, err := newClient(cfg, opts)
+				if err != nil {
+					return err
+				}
+
+				webhook, err := client.GetWebhook(cmd.Context(), args[0])
+				if err != nil {
+					return err
+				}
+
+				d.URL = webhook.URL
+				if d.Secret == "" {
+					d.Secret = webhook.Secret
+				}
+			}
+
+			if d.Secret == "" {
+				return &UsageError{Err: errors.New("a secret is required to sign the delivery, use --secret")}
+			}
+
+			req, err := delivery.NewRequest(cmd.Context(), d, time.Now())
+			if err != nil {
+				return err
+			}
+
+			start := time.Now()
+
+			client := &http.Client{Timeout: forwardTimeout}
+
+			// Failing to reach the endpoint, or timing out, exits like any
+			// other network failure.
+			resp, err := client.Do(req)
+			if err != nil {
+				if ctxErr := cmd.Context().Err(); ctxErr != nil {
+					return ctxErr
+				}
+
+				return &api.NetworkError{Err: err}
+			}
+
+			defer resp.Body.Close()
+
+			snippet, err := io.ReadAll(io.LimitReader(resp.Body, responseSnippetSize))
+			if err != nil {
+				return &api.NetworkError{Err: err}
+			}
+
+			result := sendTestResult{
+				DeliveryID:   d.ID,
+				EventID:      d.Payload.ID,
+				EventType:    d.Payload.Type,
+				URL:          d.URL,
+				Status:       resp.StatusCode,
+				DurationMS:   time.Since(start).Milliseconds(),
+				ResponseBody: string(snippet),
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), result); ok {
+				if err != nil {
+					return err
+				}
+			} else {
+				cmd.Printf("Sent %s (%s) to %s\n", result.EventType, result.EventID, result.URL)
+				cmd.Printf("Response: %s in %dms\n", resp.Status, result.DurationMS)
+
+				if len(snippet) > 0 {
+					cmd.Printf("\n%s\n", snippet)
+				}
+			}
+
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				return fmt.Errorf("endpoint responded with %s", resp.Status)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagType, "", "Event type of the delivery")
+	cmd.Flags().String(flagData, "{}", "Event data, or @file to read it from a file")
+	cmd.Flags().String(flagSecret, "", "Webhook secret, defaults to the webhook's own secret")
+
+	if err := cmd.MarkFlagRequired(flagType); err != nil {
+		panic(err)
+	}
+
+	return cmd
+}
+
+func isURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,129 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/inspect"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagHost       = "host"
+	flagPort       = "port"
+	flagSecret     = "secret"
+	flagBufferSize = "buffer-size"
+
+	defaultServeHost       = "127.0.0.1"
+	defaultServePort       = 9000
+	defaultServeBufferSize = 100
+	shutdownTimeout        = 5 * time.Second
+	readHeaderTimeout      = 10 * time.Second
+)
+
+func NewCmdWebhookServe(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Run a local server that receives and inspects deliveries",
+		Args:  cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo webhook serve --port 9000
+			xibugo webhook serve --port 9000 --secret whsec_...
+			curl http://127.0.0.1:9000/_inspect
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			addr := net.JoinHostPort(viper.GetString(flagHost), strconv.Itoa(viper.GetInt(flagPort)))
+
+			ln, err := net.Listen("tcp", addr)
+			if err != nil {
+				return err
+			}
+
+			srv := &http.Server{
+				Handler: &inspect.Server{
+					Secret: viper.GetString(flagSecret),
+					Ring:   inspect.NewRing(viper.GetInt(flagBufferSize)),
+					Out:    cmd.OutOrStdout(),
+				},
+				ReadHeaderTimeout: readHeaderTimeout,
+			}
+
+			base := fmt.Sprintf("http://%s", ln.Addr())
+			cmd.PrintErrf("Receiving deliveries on %s\n", base)
+			cmd.PrintErrf("Inspect them at %s%s (press Ctrl+C to quit)\n\n", base, inspect.InspectPath)
+
+			return serve(cmd.Context(), srv, ln)
+		},
+	}
+
+	cmd.Flags().String(flagHost, defaultServeHost, "Address to listen on")
+	cmd.Flags().Int(flagPort, defaultServePort, "Port to listen on")
+	cmd.Flags().String(flagSecret, "", "Webhook secret used to verify signatures")
+	cmd.Flags().Int(flagBufferSize, defaultServeBufferSize, "Number of deliveries kept for inspection")
+
+	return cmd
+}
+
+// serve runs srv on ln until an interrupt is received, then shuts it down
+// gracefully.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/inspect"
+	"github.com/getumbeluzi/xibugo-cli/pkg/signature"
+)
+
+func TestServe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ring := inspect.NewRing(10)
+	srv := &http.Server{
+		Handler:           &inspect.Server{Secret: "whsec_test", Ring: ring},
+		ReadHeaderTimeout: readHeaderTimeout,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- serve(ctx, srv, ln)
+	}()
+
+	url := "http://" + ln.Addr().String() + "/hook"
+	body := `{"type":"order.created"}`
+
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+	}{
+		{name: "signed", header: signature.Sign("whsec_test", time.Now(), []byte(body)), status: http.StatusNoContent},
+		{name: "bad signature", header: signature.Sign("whsec_other", time.Now(), []byte(body)), status: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header = tt.header
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tt.status {
+			t.Errorf("%s: got status %d, want %d", tt.name, resp.StatusCode, tt.status)
+		}
+	}
+
+	if got := len(ring.List()); got != len(tests) {
+		t.Errorf("recorded %d deliveries, want %d", got, len(tests))
+	}
+
+	cancel()
+
+	select {
+	case err := <-errc:
+		if err != nil {
+			t.Fatalf("serve returned %v, want a clean shutdown", err)
+		}
+	case <-time.After(shutdownTimeout):
+		t.Fatal("serve did not return after the context ended")
+	}
+}
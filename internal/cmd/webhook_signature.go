@@ -0,0 +1,203 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/pkg/signature"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagHeader    = "header"
+	flagTimestamp = "timestamp"
+	flagBody      = "body"
+	flagTolerance = "tolerance"
+)
+
+func NewCmdWebhookSign(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sign",
+		Short: "Compute the signature headers of a payload",
+		Args:  cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo webhook sign --secret whsec_... --body @payload.json
+			xibugo webhook sign --secret whsec_... --body @payload.json -o json
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			body, err := readData(viper.GetString(flagBody), cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			now := time.Now()
+			if ts := viper.GetInt64(flagTimestamp); ts != 0 {
+				now = time.Unix(ts, 0)
+			}
+
+			header := signature.Sign(viper.GetString(flagSecret), now, body)
+
+			headers := map[string]string{
+				signature.HeaderTimestamp: header.Get(signature.HeaderTimestamp),
+				signature.HeaderSignature: header.Get(signature.HeaderSignature),
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), headers); ok {
+				return err
+			}
+
+			cmd.Printf("%s: %s\n", signature.HeaderSignature, headers[signature.HeaderSignature])
+			cmd.Printf("%s: %s\n", signature.HeaderTimestamp, headers[signature.HeaderTimestamp])
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagSecret, "", "Webhook secret")
+	cmd.Flags().String(flagBody, "", "Payload, or @file to read it from a file")
+	cmd.Flags().Int64(flagTimestamp, 0, "Unix timestamp to sign with, defaults to now")
+
+	for _, name := range []string{flagSecret, flagBody} {
+		if err := cmd.MarkFlagRequired(name); err != nil {
+			panic(err)
+		}
+	}
+
+	return cmd
+}
+
+type verifyResult struct {
+	Valid     bool   `json:"valid"`
+	Error     string `json:"error,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	Expected  string `json:"expected,omitempty"`
+}
+
+func NewCmdWebhookVerify(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify the signature of a delivery",
+		Args:  cobra.NoArgs,
+		Example: heredoc.Doc(`
+			xibugo webhook verify --secret whsec_... \
+				--header 'X-Xigubo-Signature: v1=5257a869...' \
+				--timestamp 1792386360 \
+				--body @payload.json
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			header, err := parseHeaders(viper.GetStringSlice(flagHeader))
+			if err != nil {
+				return err
+			}
+
+			timestamp := viper.GetString(flagTimestamp)
+			if timestamp == "" {
+				timestamp = header.Get(signature.HeaderTimestamp)
+			}
+
+			body, err := readData(viper.GetString(flagBody), cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+
+			verifier := &signature.Verifier{
+				Secret:    viper.GetString(flagSecret),
+				Tolerance: viper.GetDuration(flagTolerance),
+			}
+
+			if verifier.Tolerance == 0 {
+				verifier.Tolerance = -1
+			}
+
+			var result verifyResult
+
+			err = verifier.Verify(header.Get(signature.HeaderSignature), timestamp, body)
+			result.Valid = err == nil
+
+			if err != nil {
+				result.Error = err.Error()
+			}
+
+			if ts, err := strconv.ParseInt(timestamp, 10, 64); err == nil {
+				result.Timestamp = ts
+				result.Expected = signature.Scheme + "=" + signature.Compute(verifier.Secret, ts, body)
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), result); ok {
+				if err != nil {
+					return err
+				}
+			} else {
+				printVerifyResult(cmd, result)
+			}
+
+			if !result.Valid {
+				return errors.New("signature verification failed")
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().String(flagSecret, "", "Webhook secret")
+	cmd.Flags().StringArray(flagHeader, nil, "Delivery header as \"Name: value\", can be repeated")
+	cmd.Flags().String(flagTimestamp, "", "Delivery timestamp, defaults to the X-Xigubo-Timestamp header")
+	cmd.Flags().String(flagBody, "", "Delivery body, or @file to read it from a file")
+	cmd.Flags().Duration(flagTolerance, signature.DefaultTolerance, "Maximum age of the timestamp, 0 to disable the check")
+
+	for _, name := range []string{flagSecret, flagBody} {
+		if err := cmd.MarkFlagRequired(name); err != nil {
+			panic(err)
+		}
+	}
+
+	return cmd
+}
+
+func printVerifyResult(cmd *cobra.Command, result verifyResult) {
+	if result.Valid {
+		cmd.Println("Signature is valid")
+		return
+	}
+
+	cmd.Printf("Signature is invalid: %s\n", result.Error)
+
+	if result.Timestamp != 0 {
+		cmd.Printf("  Timestamp: %d (%s)\n", result.Timestamp, time.Unix(result.Timestamp, 0).UTC().Format(time.RFC3339))
+		cmd.Printf("  Expected:  %s\n", result.Expected)
+	}
+}
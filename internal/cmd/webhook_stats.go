@@ -0,0 +1,278 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/MakeNowJust/heredoc/v2"
+	"github.com/getumbeluzi/xibugo-cli/internal"
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+const (
+	flagWindow           = "window"
+	flagAutoDisableAfter = "auto-disable-after"
+
+	defaultStatsWindow       = 24 * time.Hour
+	defaultAutoDisableStreak = 50
+
+	// statusNoResponse is the histogram bucket for attempts that never got
+	// an HTTP response, such as connection failures and timeouts.
+	statusNoResponse = "none"
+)
+
+type latencyStats struct {
+	P50 int64 `json:"p50_ms"`
+	P95 int64 `json:"p95_ms"`
+	P99 int64 `json:"p99_ms"`
+}
+
+type webhookStats struct {
+	WebhookID     string         `json:"webhook_id"`
+	URL           string         `json:"url"`
+	Window        string         `json:"window"`
+	Attempts      int            `json:"attempts"`
+	Succeeded     int            `json:"succeeded"`
+	Failed        int            `json:"failed"`
+	SuccessRate   float64        `json:"success_rate"`
+	Latency       latencyStats   `json:"latency"`
+	StatusCodes   map[string]int `json:"status_codes"`
+	FailureStreak int            `json:"failure_streak"`
+	Disabled      bool           `json:"disabled"`
+	AutoDisabled  bool           `json:"auto_disabled"`
+	LastAttemptAt *time.Time     `json:"last_attempt_at,omitempty"`
+}
+
+func NewCmdWebhookStats(opts *internal.CommandOptions) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "stats <webhook-id>",
+		Short: "Show delivery health statistics for a webhook",
+		Long: heredoc.Doc(`
+			Show delivery health statistics for a webhook, computed from its
+			delivery attempts within --window.
+
+			The failure streak is the number of consecutive failed attempts at
+			the end of the window. Only attempts within the window are counted,
+			so when every one of them failed the streak may have started earlier.
+
+			Disabled reports whether deliveries to the webhook are switched off.
+			Auto-disabled reports whether that is down to a failure streak of at
+			least --auto-disable-after attempts, rather than to someone disabling
+			the webhook.
+		`),
+		Args: cobra.ExactArgs(1),
+		Example: heredoc.Doc(`
+			xibugo webhook stats 123
+			xibugo webhook stats 123 --window 1h -o json
+		`),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if err := viper.BindPFlags(cmd.Flags()); err != nil {
+				panic(err)
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			internal.SetupIO(cmd, opts)
+
+			window := viper.GetDuration(flagWindow)
+			if window <= 0 {
+				return &UsageError{Err: errors.New("window must be positive")}
+			}
+
+			cfg, err := config.New()
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			webhook, err := client.GetWebhook(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+
+			listOpts := api.AttemptListOptions{CreatedAfter: time.Now().Add(-window)}
+
+			attempts, err := collectAttempts(cmd.Context(), func(ctx context.Context, o api.AttemptListOptions) ([]api.Attempt, *api.Pagination, error) {
+				return client.ListWebhookAttempts(ctx, webhook.ID, o)
+			}, listOpts)
+			if err != nil {
+				return err
+			}
+
+			threshold := viper.GetInt(flagAutoDisableAfter)
+			if threshold <= 0 {
+				return &UsageError{Err: errors.New("auto-disable-after must be positive")}
+			}
+
+			stats := computeWebhookStats(webhook, attempts, threshold)
+			stats.Window = window.String()
+
+			if ok, err := printStructured(cmd.OutOrStdout(), stats); ok {
+				return err
+			}
+
+			return printWebhookStats(cmd, stats)
+		},
+	}
+
+	cmd.Flags().Duration(flagWindow, defaultStatsWindow, "Period of delivery attempts to report on")
+	cmd.Flags().Int(flagAutoDisableAfter, defaultAutoDisableStreak, "Failure streak after which the API disables a webhook")
+
+	return cmd
+}
+
+// computeWebhookStats summarises attempts to webhook. Latency percentiles
+// only cover attempts that got a response, as the duration of the others is
+// that of a connection failure or timeout. A disabled webhook counts as
+// auto-disabled when its failure streak reached autoDisableStreak.
+func computeWebhookStats(webhook *api.Webhook, attempts []api.Attempt, autoDisableStreak int) webhookStats {
+	stats := webhookStats{
+		WebhookID:   webhook.ID,
+		URL:         webhook.URL,
+		Attempts:    len(attempts),
+		StatusCodes: map[string]int{},
+		Disabled:    webhook.Disabled,
+	}
+
+	if len(attempts) == 0 {
+		return stats
+	}
+
+	sort.Slice(attempts, func(i, j int) bool {
+		return attempts[i].CreatedAt.Before(attempts[j].CreatedAt)
+	})
+
+	durations := make([]int64, 0, len(attempts))
+
+	for _, a := range attempts {
+		if a.Status == api.AttemptStatusSucceeded {
+			stats.Succeeded++
+			stats.FailureStreak = 0
+		} else {
+			stats.Failed++
+			stats.FailureStreak++
+		}
+
+		code := statusNoResponse
+		if a.ResponseStatus != 0 {
+			code = strconv.Itoa(a.ResponseStatus)
+		}
+
+		stats.StatusCodes[code]++
+
+		if a.ResponseStatus != 0 {
+			durations = append(durations, a.DurationMS)
+		}
+	}
+
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	stats.SuccessRate = float64(stats.Succeeded) / float64(len(attempts))
+	stats.Latency = latencyStats{
+		P50: percentile(durations, 50),
+		P95: percentile(durations, 95),
+		P99: percentile(durations, 99),
+	}
+
+	last := attempts[len(attempts)-1].CreatedAt
+	stats.LastAttemptAt = &last
+	stats.AutoDisabled = webhook.Disabled && stats.FailureStreak >= autoDisableStreak
+
+	return stats
+}
+
+// percentile returns the nearest-rank percentile p of sorted values.
+func percentile(sorted []int64, p float64) int64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
+
+func printWebhookStats(cmd *cobra.Command, stats webhookStats) error {
+	lastAttempt := "-"
+	if stats.LastAttemptAt != nil {
+		lastAttempt = stats.LastAttemptAt.Local().Format(time.RFC3339)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Webhook:\t%s\n", stats.WebhookID)
+	fmt.Fprintf(w, "URL:\t%s\n", stats.URL)
+	fmt.Fprintf(w, "Window:\t%s\n", stats.Window)
+	fmt.Fprintf(w, "Attempts:\t%d (%d succeeded, %d failed)\n", stats.Attempts, stats.Succeeded, stats.Failed)
+	fmt.Fprintf(w, "Success rate:\t%.1f%%\n", stats.SuccessRate*100)
+	fmt.Fprintf(w, "Latency:\tp50 %dms, p95 %dms, p99 %dms\n", stats.Latency.P50, stats.Latency.P95, stats.Latency.P99)
+	fmt.Fprintf(w, "Failure streak:\t%d\n", stats.FailureStreak)
+	fmt.Fprintf(w, "Disabled:\t%s\n", yesNo(stats.Disabled))
+	fmt.Fprintf(w, "Auto-disabled:\t%s\n", yesNo(stats.AutoDisabled))
+	fmt.Fprintf(w, "Last attempt:\t%s\n", lastAttempt)
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if len(stats.StatusCodes) == 0 {
+		return nil
+	}
+
+	codes := make([]string, 0, len(stats.StatusCodes))
+	for code := range stats.StatusCodes {
+		codes = append(codes, code)
+	}
+
+	sort.Strings(codes)
+
+	cmd.Println()
+
+	w = tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "HTTP\tCOUNT\tSHARE")
+
+	for _, code := range codes {
+		n := stats.StatusCodes[code]
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", code, n, float64(n)/float64(stats.Attempts)*100)
+	}
+
+	return w.Flush()
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
@@ -0,0 +1,113 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+func TestComputeWebhookStats(t *testing.T) {
+	start := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// attempt returns an attempt made i seconds after start. A zero status
+	// stands for one that got no response.
+	attempt := func(i, status int, ms int64) api.Attempt {
+		a := api.Attempt{
+			Status:         api.AttemptStatusFailed,
+			ResponseStatus: status,
+			DurationMS:     ms,
+			CreatedAt:      start.Add(time.Duration(i) * time.Second),
+		}
+
+		if status >= 200 && status < 300 {
+			a.Status = api.AttemptStatusSucceeded
+		}
+
+		return a
+	}
+
+	tests := []struct {
+		name     string
+		disabled bool
+		attempts []api.Attempt
+		streak   int
+		latency  latencyStats
+		auto     bool
+	}{
+		{
+			name: "no attempts",
+		},
+		{
+			name:     "timeouts left out of latency",
+			attempts: []api.Attempt{attempt(0, 200, 10), attempt(1, 0, 30000), attempt(2, 200, 20), attempt(3, 0, 30000)},
+			streak:   1,
+			latency:  latencyStats{P50: 10, P95: 20, P99: 20},
+		},
+		{
+			name:     "no responses at all",
+			attempts: []api.Attempt{attempt(0, 0, 30000), attempt(1, 0, 30000)},
+			streak:   2,
+		},
+		{
+			name:     "disabled after a failure streak",
+			disabled: true,
+			attempts: []api.Attempt{attempt(3, 500, 5), attempt(0, 200, 5), attempt(2, 500, 5), attempt(1, 0, 30000)},
+			streak:   3,
+			latency:  latencyStats{P50: 5, P95: 5, P99: 5},
+			auto:     true,
+		},
+		{
+			name:     "disabled by hand",
+			disabled: true,
+			attempts: []api.Attempt{attempt(0, 500, 5), attempt(1, 200, 5)},
+			latency:  latencyStats{P50: 5, P95: 5, P99: 5},
+		},
+		{
+			name:     "failing but still enabled",
+			attempts: []api.Attempt{attempt(0, 500, 5), attempt(1, 500, 5), attempt(2, 500, 5)},
+			streak:   3,
+			latency:  latencyStats{P50: 5, P95: 5, P99: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			webhook := &api.Webhook{ID: "wh_1", Disabled: tt.disabled}
+
+			stats := computeWebhookStats(webhook, tt.attempts, 3)
+
+			if stats.FailureStreak != tt.streak {
+				t.Errorf("got failure streak %d, want %d", stats.FailureStreak, tt.streak)
+			}
+
+			if stats.Latency != tt.latency {
+				t.Errorf("got latency %+v, want %+v", stats.Latency, tt.latency)
+			}
+
+			if stats.Disabled != tt.disabled {
+				t.Errorf("got disabled %t, want %t", stats.Disabled, tt.disabled)
+			}
+
+			if stats.AutoDisabled != tt.auto {
+				t.Errorf("got auto-disabled %t, want %t", stats.AutoDisabled, tt.auto)
+			}
+		})
+	}
+}
@@ -34,7 +34,24 @@ func NewCmdWhoami(opts *internal.CommandOptions) *cobra.Command {
 				return err
 			}
 
-			cmd.Println(cfg)
+			client, err := newClient(cfg, opts)
+			if err != nil {
+				return err
+			}
+
+			account, err := client.GetAccount(cmd.Context())
+			if err != nil {
+				return err
+			}
+
+			if ok, err := printStructured(cmd.OutOrStdout(), account); ok {
+				return err
+			}
+
+			cmd.Printf("Account:  %s\n", account.ID)
+			cmd.Printf("Name:     %s\n", account.Name)
+			cmd.Printf("Email:    %s\n", account.Email)
+			cmd.Printf("Endpoint: %s\n", cfg.Endpoint())
 
 			return nil
 		},
@@ -0,0 +1,571 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package codegen generates source code from event type schemas.
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+// File is a generated source file, relative to the output directory.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+const generatedHeader = "// Code generated by xibugo event-type codegen. DO NOT EDIT.\n\n"
+
+// initialisms are written in upper case in Go identifiers.
+var initialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SKU": true, "SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// Go generates a package with a struct per event type, derived from its
+// schema, and a dispatcher decoding deliveries into them.
+func Go(pkg string, eventTypes []api.EventType) ([]File, error) {
+	g := &goGenerator{names: map[string]bool{}, imports: map[string]bool{}}
+
+	sorted := make([]api.EventType, len(eventTypes))
+	copy(sorted, eventTypes)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	events := make([]goEvent, 0, len(sorted))
+	idents := map[string]bool{}
+
+	for _, et := range sorted {
+		ident := identifier(et.Name)
+		for i := 2; idents[ident]; i++ {
+			ident = identifier(et.Name) + strconv.Itoa(i)
+		}
+
+		idents[ident] = true
+		ev := goEvent{Name: et.Name, Ident: ident}
+
+		// The dispatcher, which imports encoding/json itself, is the only
+		// place the data of event types without a schema is referred to.
+		if len(et.Schema) == 0 || string(et.Schema) == "null" {
+			ev.DataType = "json.RawMessage"
+		} else {
+			var root interface{}
+			if err := json.Unmarshal(et.Schema, &root); err != nil {
+				return nil, fmt.Errorf("event type %s: parsing schema: %w", et.Name, err)
+			}
+
+			g.root, g.prefix = root, ident
+			g.defs = map[string]string{"#": "json.RawMessage"}
+
+			// A schema referring to itself, as trees do, gets a pointer to
+			// the struct generated for it.
+			if obj, ok := root.(map[string]interface{}); ok && obj["properties"] != nil {
+				g.defs["#"] = "*" + ident
+			}
+
+			typ, err := g.typeFor(root, ev.Ident, et.Description, true)
+			if err != nil {
+				return nil, fmt.Errorf("event type %s: %w", et.Name, err)
+			}
+
+			ev.DataType = typ
+		}
+
+		events = append(events, ev)
+	}
+
+	types, err := g.typesFile(pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	dispatch, err := dispatchFile(pkg, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return []File{{Name: "types.go", Content: types}, {Name: "dispatch.go", Content: dispatch}}, nil
+}
+
+type goEvent struct {
+	Name     string
+	Ident    string
+	DataType string
+}
+
+type goGenerator struct {
+	decls   []string
+	names   map[string]bool
+	imports map[string]bool
+
+	// root, prefix and defs belong to the schema being generated. defs maps
+	// the $ref of a definition to the type generated for it, which is named
+	// after the event type to keep definitions of different schemas apart.
+	root   interface{}
+	prefix string
+	defs   map[string]string
+}
+
+// reserve makes name unique among the generated declarations.
+func (g *goGenerator) reserve(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+
+	g.names[unique] = true
+
+	return unique
+}
+
+// typeFor returns the Go type of a schema, declaring named types for objects
+// and enums. named forces a declaration, as for the top level of an event.
+func (g *goGenerator) typeFor(node interface{}, name, doc string, named bool) (string, error) {
+	s, ok := node.(map[string]interface{})
+	if !ok {
+		// true, false and anything unexpected accept any value.
+		return g.any(name, doc, named), nil
+	}
+
+	if d, ok := s["description"].(string); ok && doc == "" {
+		doc = d
+	}
+
+	if ref, ok := s["$ref"].(string); ok {
+		return g.ref(ref)
+	}
+
+	types := schemaTypes(s)
+
+	nullable := false
+	if len(types) == 2 && (types[0] == "null" || types[1] == "null") {
+		nullable = true
+
+		if types[0] == "null" {
+			types = types[1:]
+		} else {
+			types = types[:1]
+		}
+	}
+
+	if len(types) == 0 {
+		switch {
+		case s["properties"] != nil:
+			types = []string{"object"}
+		case s["items"] != nil:
+			types = []string{"array"}
+		case s["enum"] != nil:
+			types = []string{"string"}
+		}
+	}
+
+	if len(types) != 1 {
+		return g.any(name, doc, named), nil
+	}
+
+	var (
+		typ string
+		err error
+	)
+
+	switch types[0] {
+	case "string":
+		typ = g.stringType(s, name, doc)
+	case "integer":
+		typ = "int64"
+	case "number":
+		typ = "float64"
+	case "boolean":
+		typ = "bool"
+	case "array":
+		var item string
+
+		item, err = g.typeFor(s["items"], name+"Item", "", false)
+		typ = "[]" + item
+	case "object":
+		typ, err = g.objectType(s, name, doc)
+	default:
+		typ = g.any(name, doc, named)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if named && !g.declared(typ) {
+		typ = g.declare(name, doc, typ)
+	}
+
+	if nullable && !strings.HasPrefix(typ, "[]") && !strings.HasPrefix(typ, "map[") && typ != "json.RawMessage" {
+		typ = "*" + typ
+	}
+
+	return typ, nil
+}
+
+func (g *goGenerator) declared(typ string) bool {
+	return g.names[typ]
+}
+
+// declare adds "type name typ" and returns the declared name.
+func (g *goGenerator) declare(name, doc, typ string) string {
+	name = g.reserve(name)
+	g.decls = append(g.decls, comment(doc)+fmt.Sprintf("type %s %s\n", name, typ))
+
+	return name
+}
+
+func (g *goGenerator) any(name, doc string, named bool) string {
+	g.imports["encoding/json"] = true
+
+	if named {
+		return g.declare(name, doc, "= json.RawMessage")
+	}
+
+	return "json.RawMessage"
+}
+
+func (g *goGenerator) ref(ref string) (string, error) {
+	if typ, ok := g.defs[ref]; ok {
+		if typ == "json.RawMessage" {
+			g.imports["encoding/json"] = true
+		}
+
+		return typ, nil
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return "", fmt.Errorf("unsupported $ref %q, only references within the schema are resolved", ref)
+	}
+
+	tokens := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+
+	node := g.root
+	for _, token := range tokens {
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return "", fmt.Errorf("unresolvable $ref %q", ref)
+		}
+
+		if node, ok = obj[strings.NewReplacer("~1", "/", "~0", "~").Replace(token)]; !ok {
+			return "", fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+
+	// Reserve the name before generating, so recursive definitions refer
+	// to it rather than expanding forever.
+	name := g.prefix + identifier(tokens[len(tokens)-1])
+	g.defs[ref] = "json.RawMessage"
+
+	typ, err := g.typeFor(node, name, "", true)
+	if err != nil {
+		return "", err
+	}
+
+	g.defs[ref] = typ
+
+	return typ, nil
+}
+
+func (g *goGenerator) stringType(s map[string]interface{}, name, doc string) string {
+	if format, _ := s["format"].(string); format == "date-time" {
+		g.imports["time"] = true
+		return "time.Time"
+	}
+
+	enum, ok := s["enum"].([]interface{})
+	if !ok || len(enum) == 0 {
+		return "string"
+	}
+
+	typ := g.declare(name, doc, "string")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "const (\n")
+
+	for _, v := range enum {
+		value, ok := v.(string)
+		if !ok {
+			continue
+		}
+
+		fmt.Fprintf(&b, "\t%s %s = %q\n", g.reserve(typ+identifier(value)), typ, value)
+	}
+
+	fmt.Fprintf(&b, ")\n")
+	g.decls = append(g.decls, b.String())
+
+	return typ
+}
+
+func (g *goGenerator) objectType(s map[string]interface{}, name, doc string) (string, error) {
+	props, _ := s["properties"].(map[string]interface{})
+
+	if len(props) == 0 {
+		value := "interface{}"
+
+		if additional, ok := s["additionalProperties"].(map[string]interface{}); ok {
+			typ, err := g.typeFor(additional, name+"Value", "", false)
+			if err != nil {
+				return "", err
+			}
+
+			value = typ
+		}
+
+		return "map[string]" + value, nil
+	}
+
+	required := map[string]bool{}
+	if req, ok := s["required"].([]interface{}); ok {
+		for _, r := range req {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	// Reserve the struct name before its fields, so nested types are named
+	// after it.
+	name = g.reserve(name)
+
+	var b strings.Builder
+	b.WriteString(comment(doc))
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+
+	fields := map[string]bool{}
+
+	for _, key := range keys {
+		field := identifier(key)
+		for i := 2; fields[field]; i++ {
+			field = identifier(key) + strconv.Itoa(i)
+		}
+
+		fields[field] = true
+
+		var fieldDoc string
+		if p, ok := props[key].(map[string]interface{}); ok {
+			fieldDoc, _ = p["description"].(string)
+		}
+
+		typ, err := g.typeFor(props[key], name+field, "", false)
+		if err != nil {
+			return "", err
+		}
+
+		tag := key
+		if !required[key] {
+			tag += ",omitempty"
+
+			if isScalar(typ) {
+				typ = "*" + typ
+			}
+		}
+
+		if fieldDoc != "" {
+			for _, line := range strings.Split(strings.TrimSpace(fieldDoc), "\n") {
+				fmt.Fprintf(&b, "\t// %s\n", line)
+			}
+		}
+
+		fmt.Fprintf(&b, "\t%s %s `json:%q`\n", field, typ, tag)
+	}
+
+	b.WriteString("}\n")
+	g.decls = append(g.decls, b.String())
+
+	return name, nil
+}
+
+func (g *goGenerator) typesFile(pkg string) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString(generatedHeader)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+
+	if len(g.imports) > 0 {
+		imports := make([]string, 0, len(g.imports))
+		for imp := range g.imports {
+			imports = append(imports, strconv.Quote(imp))
+		}
+
+		sort.Strings(imports)
+		fmt.Fprintf(&b, "import (\n%s\n)\n\n", strings.Join(imports, "\n"))
+	}
+
+	b.WriteString(strings.Join(g.decls, "\n"))
+
+	return formatSource("types.go", b.Bytes())
+}
+
+func dispatchFile(pkg string, events []goEvent) ([]byte, error) {
+	var b bytes.Buffer
+
+	b.WriteString(generatedHeader)
+	fmt.Fprintf(&b, "package %s\n\n", pkg)
+	b.WriteString(`import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Event is the payload of a webhook delivery.
+type Event struct {
+	ID        string          ` + "`json:\"id\"`" + `
+	Type      string          ` + "`json:\"type\"`" + `
+	CreatedAt time.Time       ` + "`json:\"created_at\"`" + `
+	Data      json.RawMessage ` + "`json:\"data\"`" + `
+}
+
+// ErrUnknownEventType is returned by Dispatch for event types that were not
+// known when this package was generated.
+var ErrUnknownEventType = errors.New("unknown event type")
+
+`)
+
+	if len(events) > 0 {
+		b.WriteString("// Event types.\nconst (\n")
+
+		for _, ev := range events {
+			fmt.Fprintf(&b, "\tType%s = %q\n", ev.Ident, ev.Name)
+		}
+
+		b.WriteString(")\n\n")
+	}
+
+	b.WriteString("// Handlers holds a function per event type. Events without a handler are\n// ignored.\ntype Handlers struct {\n")
+
+	for _, ev := range events {
+		fmt.Fprintf(&b, "\t%s func(ctx context.Context, event Event, data %s) error\n", ev.Ident, ev.DataType)
+	}
+
+	b.WriteString(`}
+
+// Parse decodes the body of a webhook delivery.
+func Parse(body []byte) (Event, error) {
+	var event Event
+	err := json.Unmarshal(body, &event)
+
+	return event, err
+}
+
+// Dispatch decodes the data of event into the type generated for its event
+// type and calls the matching handler.
+func (h *Handlers) Dispatch(ctx context.Context, event Event) error {
+	switch event.Type {
+`)
+
+	for _, ev := range events {
+		fmt.Fprintf(&b, "\tcase Type%s:\n", ev.Ident)
+		fmt.Fprintf(&b, "\t\tif h.%s == nil {\n\t\t\treturn nil\n\t\t}\n\n", ev.Ident)
+		fmt.Fprintf(&b, "\t\tvar data %s\n", ev.DataType)
+		b.WriteString("\t\tif err := json.Unmarshal(event.Data, &data); err != nil {\n")
+		b.WriteString("\t\t\treturn fmt.Errorf(\"decoding %s data: %w\", event.Type, err)\n\t\t}\n\n")
+		fmt.Fprintf(&b, "\t\treturn h.%s(ctx, event, data)\n", ev.Ident)
+	}
+
+	b.WriteString("\t}\n\n\treturn fmt.Errorf(\"%w: %s\", ErrUnknownEventType, event.Type)\n}\n")
+
+	return formatSource("dispatch.go", b.Bytes())
+}
+
+func formatSource(name string, src []byte) ([]byte, error) {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, fmt.Errorf("formatting %s: %w", name, err)
+	}
+
+	return formatted, nil
+}
+
+func schemaTypes(s map[string]interface{}) []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+
+		return types
+	}
+
+	return nil
+}
+
+func isScalar(typ string) bool {
+	return !strings.HasPrefix(typ, "[]") &&
+		!strings.HasPrefix(typ, "map[") &&
+		!strings.HasPrefix(typ, "*") &&
+		typ != "json.RawMessage"
+}
+
+func comment(doc string) string {
+	doc = strings.TrimSpace(doc)
+	if doc == "" {
+		return ""
+	}
+
+	return "// " + strings.Join(strings.Split(doc, "\n"), "\n// ") + "\n"
+}
+
+// identifier converts a name such as "order.created" or "customer_id" into
+// an exported Go identifier, OrderCreated or CustomerID.
+func identifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+
+		runes := []rune(w)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+
+	id := b.String()
+	if id == "" || unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+
+	return id
+}
@@ -0,0 +1,143 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package codegen
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+func TestGoCompiles(t *testing.T) {
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go toolchain not found")
+	}
+
+	eventType := func(name, schema string) api.EventType {
+		et := api.EventType{Name: name, Description: "The " + name + " event"}
+		if schema != "" {
+			et.Schema = json.RawMessage(schema)
+		}
+
+		return et
+	}
+
+	tests := []struct {
+		name       string
+		eventTypes []api.EventType
+	}{
+		{
+			name: "no event types",
+		},
+		{
+			name: "scalars and formats",
+			eventTypes: []api.EventType{eventType("order.created", `{
+				"type": "object",
+				"required": ["id", "total"],
+				"properties": {
+					"id": {"type": "string"},
+					"total": {"type": "number"},
+					"quantity": {"type": "integer"},
+					"paid": {"type": "boolean"},
+					"placed_at": {"type": "string", "format": "date-time"},
+					"status": {"enum": ["pending", "paid"]},
+					"note": {"type": ["string", "null"]},
+					"metadata": {"type": "object", "additionalProperties": {"type": "string"}}
+				}
+			}`)},
+		},
+		{
+			name: "nested objects, arrays and references",
+			eventTypes: []api.EventType{eventType("order.shipped", `{
+				"type": "object",
+				"properties": {
+					"address": {"type": "object", "properties": {"line_1": {"type": "string"}, "zip-code": {"type": "string"}}},
+					"items": {"type": "array", "items": {"$ref": "#/$defs/item"}},
+					"tags": {"type": "array", "items": {"type": "string"}}
+				},
+				"$defs": {
+					"item": {"type": "object", "properties": {"sku": {"type": "string"}, "url": {"type": "string"}}}
+				}
+			}`)},
+		},
+		{
+			name: "recursive schema",
+			eventTypes: []api.EventType{eventType("category.updated", `{
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#"}}
+				}
+			}`)},
+		},
+		{
+			name: "combinators and anything",
+			eventTypes: []api.EventType{eventType("payment.settled", `{
+				"type": "object",
+				"properties": {
+					"method": {"oneOf": [{"type": "string"}, {"type": "object"}]},
+					"details": {"allOf": [{"type": "object", "properties": {"a": {"type": "string"}}}]},
+					"raw": true
+				}
+			}`)},
+		},
+		{
+			name: "clashing names and no schema",
+			eventTypes: []api.EventType{
+				eventType("order.created", `{"type": "object", "properties": {"id": {"type": "string"}}}`),
+				eventType("order_created", `{"type": "object", "properties": {"id": {"type": "integer"}}}`),
+				eventType("ping", ""),
+				eventType("3d.rendered", `{"type": "string"}`),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := Go("events", tt.eventTypes)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			dir := t.TempDir()
+			files = append(files, File{Name: "go.mod", Content: []byte("module example.com/events\n\ngo 1.19\n")})
+
+			for _, f := range files {
+				if err := os.WriteFile(filepath.Join(dir, f.Name), f.Content, 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			cmd := exec.Command(gobin, "vet", "./...")
+			cmd.Dir = dir
+			cmd.Env = append(os.Environ(), "GOFLAGS=", "GOWORK=off")
+
+			if out, err := cmd.CombinedOutput(); err != nil {
+				for _, f := range files {
+					t.Logf("%s:\n%s", f.Name, f.Content)
+				}
+
+				t.Fatalf("generated code does not compile: %v\n%s", err, out)
+			}
+		})
+	}
+}
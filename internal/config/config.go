@@ -18,6 +18,10 @@ package config
 
 import (
 	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
 
 	"github.com/spf13/viper"
 )
@@ -25,15 +29,60 @@ import (
 const (
 	Production = "https://api.xibugo.com"
 	Sandbox    = "https://api.sandbox.xibugo.com"
+
+	EnvProduction = "production"
+	EnvSandbox    = "sandbox"
+
+	// EnvPrefix prefixes the environment variables settings are read from.
+	EnvPrefix = "XIGUBO"
+
+	sourceConfigFile  = "the configuration file"
+	sourceEnvironment = "the environment"
 )
 
+// endpointSettings are the settings that select the API endpoint, in order
+// of precedence.
+var endpointSettings = []string{"base-url", "env", "sandbox"}
+
+// envKeyReplacer maps setting names to those of environment variables, so
+// that base-url is read from XIGUBO_BASE_URL.
+var envKeyReplacer = strings.NewReplacer("-", "_")
+
+// BindEnv makes v read settings from the environment variables named after
+// them, see EnvVar. The endpoint settings are bound explicitly, as viper
+// only unmarshals the settings it knows of.
+func BindEnv(v *viper.Viper) {
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(envKeyReplacer)
+	v.AutomaticEnv()
+
+	for _, key := range endpointSettings {
+		_ = v.BindEnv(key)
+	}
+}
+
+var builtinEnvironments = map[string]Environment{
+	EnvProduction: {BaseURL: Production},
+	EnvSandbox:    {BaseURL: Sandbox},
+}
+
 func New() (*Config, error) {
 	return NewWithValidation(true)
 }
 
 func NewWithValidation(validation bool) (*Config, error) {
+	return fromViper(viper.GetViper(), validation)
+}
+
+// NewFromViper builds a validated configuration from the settings held by v,
+// such as those of a profile other than the active one.
+func NewFromViper(v *viper.Viper) (*Config, error) {
+	return fromViper(v, true)
+}
+
+func fromViper(v *viper.Viper, validation bool) (*Config, error) {
 	var cfg Config
-	if err := viper.Unmarshal(&cfg); err != nil {
+	if err := v.Unmarshal(&cfg); err != nil {
 		return nil, err
 	}
 
@@ -43,18 +92,27 @@ func NewWithValidation(validation bool) (*Config, error) {
 		}
 	}
 
-	if cfg.Sandbox {
-		cfg.BaseURL = Sandbox
+	if err := cfg.resolveEndpoint(v); err != nil {
+		return nil, err
 	}
 
 	return &cfg, nil
 }
 
 type Config struct {
-	Account     string `mapstructure:"account"`
-	Sandbox     bool   `mapstructure:"sandbox"`
-	AccessToken string `mapstructure:"access-token"`
-	BaseURL     string `mapstructure:"base-url"`
+	Account      string                 `mapstructure:"account"`
+	Sandbox      bool                   `mapstructure:"sandbox"`
+	AccessToken  string                 `mapstructure:"access-token"`
+	BaseURL      string                 `mapstructure:"base-url"`
+	CAFile       string                 `mapstructure:"ca-file"`
+	Env          string                 `mapstructure:"env"`
+	Environments map[string]Environment `mapstructure:"environments"`
+}
+
+// Environment is a named API deployment the CLI can talk to.
+type Environment struct {
+	BaseURL string `mapstructure:"base-url"`
+	CAFile  string `mapstructure:"ca-file"`
 }
 
 func (c Config) Validate() error {
@@ -68,3 +126,128 @@ func (c Config) Validate() error {
 
 	return nil
 }
+
+// resolveEndpoint settles the base URL from the settings that select it. A
+// base URL wins, followed by a named environment and finally the sandbox
+// switch. When none of them is set the production API is used. An endpoint
+// chosen on the command line clears the others beforehand, so that it
+// overrides those of the configuration file. Setting more than one in the
+// configuration file, or more than one in the environment, is ambiguous and
+// is rejected.
+func (c *Config) resolveEndpoint(v *viper.Viper) error {
+	if err := checkEndpointSources(v); err != nil {
+		return err
+	}
+
+	if c.BaseURL != "" {
+		return nil
+	}
+
+	if c.Env != "" {
+		env, ok := c.LookupEnvironment(c.Env)
+		if !ok {
+			return fmt.Errorf("unknown environment %q", c.Env)
+		}
+
+		c.BaseURL = env.BaseURL
+		if c.CAFile == "" {
+			c.CAFile = env.CAFile
+		}
+
+		return nil
+	}
+
+	if c.Sandbox {
+		c.BaseURL = Sandbox
+	}
+
+	return nil
+}
+
+// checkEndpointSources fails when more than one of the settings selecting
+// the endpoint is set by the same source.
+func checkEndpointSources(v *viper.Viper) error {
+	bySource := map[string][]string{}
+
+	for _, key := range endpointSettings {
+		set := v.GetString(key) != ""
+		if key == "sandbox" {
+			set = v.GetBool(key)
+		}
+
+		if !set {
+			continue
+		}
+
+		if source, ok := settingSource(v, key); ok {
+			bySource[source] = append(bySource[source], key)
+		}
+	}
+
+	for _, source := range []string{sourceEnvironment, sourceConfigFile} {
+		if keys := bySource[source]; len(keys) > 1 {
+			return fmt.Errorf("%s sets more than one of %s; keep only one", source, strings.Join(keys, ", "))
+		}
+	}
+
+	return nil
+}
+
+// settingSource reports whether the value of a setting comes from an
+// environment variable or the configuration file. Values set by flags, or
+// overridden by them, come from neither.
+func settingSource(v *viper.Viper, key string) (string, bool) {
+	if value, ok := os.LookupEnv(EnvVar(key)); ok && value == v.GetString(key) {
+		return sourceEnvironment, true
+	}
+
+	if v.InConfig(key) {
+		return sourceConfigFile, true
+	}
+
+	return "", false
+}
+
+// EnvVar returns the name of the environment variable a setting is read
+// from.
+func EnvVar(key string) string {
+	return EnvPrefix + "_" + strings.ToUpper(envKeyReplacer.Replace(key))
+}
+
+// Endpoint returns the base URL requests are sent to.
+func (c Config) Endpoint() string {
+	if c.BaseURL == "" {
+		return Production
+	}
+
+	return c.BaseURL
+}
+
+// LookupEnvironment finds an environment by name. Environments defined in
+// the configuration take precedence over the built-in ones.
+func (c Config) LookupEnvironment(name string) (Environment, bool) {
+	if env, ok := c.Environments[name]; ok {
+		return env, true
+	}
+
+	env, ok := builtinEnvironments[name]
+
+	return env, ok
+}
+
+// EnvironmentNames returns the built-in environments followed by the ones
+// defined in the configuration, sorted by name.
+func (c Config) EnvironmentNames() []string {
+	names := []string{EnvProduction, EnvSandbox}
+
+	custom := make([]string, 0, len(c.Environments))
+	for name := range c.Environments {
+		if _, ok := builtinEnvironments[name]; !ok {
+			custom = append(custom, name)
+		}
+	}
+
+	sort.Strings(custom)
+
+	return append(names, custom...)
+}
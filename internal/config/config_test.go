@@ -0,0 +1,142 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		name   string
+		file   string
+		env    map[string]string
+		flags  map[string]interface{}
+		want   string
+		errMsg string
+	}{
+		{
+			name: "nothing set",
+			want: Production,
+		},
+		{
+			name: "sandbox in the configuration file",
+			file: "sandbox: true\n",
+			want: Sandbox,
+		},
+		{
+			name: "custom environment",
+			file: "env: staging\nenvironments:\n  staging:\n    base-url: https://staging.example.com\n",
+			want: "https://staging.example.com",
+		},
+		{
+			name: "base url in the environment beats env in the configuration file",
+			file: "env: sandbox\n",
+			env:  map[string]string{"XIGUBO_BASE_URL": "https://local.example.com"},
+			want: "https://local.example.com",
+		},
+		{
+			name: "env in the environment beats sandbox in the configuration file",
+			file: "sandbox: true\n",
+			env:  map[string]string{"XIGUBO_ENV": "production"},
+			want: Production,
+		},
+		{
+			name: "environment variable beats the configuration file for the same setting",
+			file: "env: production\n",
+			env:  map[string]string{"XIGUBO_ENV": "sandbox"},
+			want: Sandbox,
+		},
+		{
+			name:  "flag overriding the configuration file",
+			file:  "base-url: https://local.example.com\nenv: staging\n",
+			flags: map[string]interface{}{"base-url": "", "env": "", "sandbox": true},
+			want:  Sandbox,
+		},
+		{
+			name:  "flag overriding the environment",
+			env:   map[string]string{"XIGUBO_ENV": "staging", "XIGUBO_SANDBOX": "true"},
+			flags: map[string]interface{}{"base-url": "https://local.example.com", "env": "", "sandbox": false},
+			want:  "https://local.example.com",
+		},
+		{
+			name:   "conflict in the configuration file",
+			file:   "base-url: https://local.example.com\nsandbox: true\n",
+			errMsg: "the configuration file sets more than one of base-url, sandbox",
+		},
+		{
+			name:   "conflict in the environment",
+			env:    map[string]string{"XIGUBO_ENV": "production", "XIGUBO_SANDBOX": "true"},
+			errMsg: "the environment sets more than one of env, sandbox",
+		},
+		{
+			name: "sandbox switched off",
+			file: "env: sandbox\n",
+			env:  map[string]string{"XIGUBO_SANDBOX": "false"},
+			want: Sandbox,
+		},
+		{
+			name:   "unknown environment",
+			file:   "env: staging\n",
+			errMsg: `unknown environment "staging"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range endpointSettings {
+				t.Setenv(EnvVar(key), "")
+			}
+
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+
+			v := viper.New()
+			BindEnv(v)
+			v.SetConfigType("yaml")
+
+			if err := v.ReadConfig(strings.NewReader("account: acc_1\naccess-token: t\n" + tt.file)); err != nil {
+				t.Fatal(err)
+			}
+
+			for key, value := range tt.flags {
+				v.Set(key, value)
+			}
+
+			cfg, err := NewFromViper(v)
+			if tt.errMsg != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+					t.Fatalf("got error %v, want one containing %q", err, tt.errMsg)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := cfg.Endpoint(); got != tt.want {
+				t.Errorf("got endpoint %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,92 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package delivery builds webhook delivery requests the way the platform
+// sends them.
+package delivery
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/pkg/signature"
+)
+
+const (
+	HeaderDeliveryID = "X-Xigubo-Delivery-Id"
+	HeaderEventID    = "X-Xigubo-Event-Id"
+	HeaderEventType  = "X-Xigubo-Event-Type"
+
+	userAgent = "Xigubo-Webhooks/1.0"
+)
+
+// Payload is the body of a delivery.
+type Payload struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Delivery is a payload addressed to a webhook endpoint.
+type Delivery struct {
+	ID      string
+	URL     string
+	Secret  string
+	Payload Payload
+}
+
+// NewRequest returns the signed POST request that delivers d.
+func NewRequest(ctx context.Context, d *Delivery, now time.Time) (*http.Request, error) {
+	body, err := json.Marshal(d.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(HeaderDeliveryID, d.ID)
+	req.Header.Set(HeaderEventID, d.Payload.ID)
+	req.Header.Set(HeaderEventType, d.Payload.Type)
+
+	if d.Secret != "" {
+		for name, values := range signature.Sign(d.Secret, now, body) {
+			req.Header[name] = values
+		}
+	}
+
+	return req, nil
+}
+
+// NewID returns a random identifier with the given prefix.
+func NewID(prefix string) string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+
+	return prefix + "_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,243 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package inspect implements a webhook receiver that records the deliveries
+// it accepts so they can be inspected while debugging an integration.
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/pkg/signature"
+)
+
+// InspectPath is where the recorded deliveries are served from.
+const InspectPath = "/_inspect"
+
+const maxBodySize = 5 << 20
+
+// Delivery is a request received by the server.
+type Delivery struct {
+	ID          int             `json:"id"`
+	ReceivedAt  time.Time       `json:"received_at"`
+	Method      string          `json:"method"`
+	Path        string          `json:"path"`
+	Headers     http.Header     `json:"headers"`
+	Body        json.RawMessage `json:"body,omitempty"`
+	RawBody     string          `json:"raw_body,omitempty"`
+	Verified    *bool           `json:"verified,omitempty"`
+	VerifyError string          `json:"verify_error,omitempty"`
+}
+
+// Ring keeps the most recent deliveries, dropping the oldest once full.
+type Ring struct {
+	mu    sync.Mutex
+	items []Delivery
+	start int
+	seq   int
+}
+
+func NewRing(size int) *Ring {
+	if size < 1 {
+		size = 1
+	}
+
+	return &Ring{items: make([]Delivery, 0, size)}
+}
+
+// Add stores d, assigning it the next ID.
+func (r *Ring) Add(d Delivery) Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.seq++
+	d.ID = r.seq
+
+	if len(r.items) < cap(r.items) {
+		r.items = append(r.items, d)
+		return d
+	}
+
+	r.items[r.start] = d
+	r.start = (r.start + 1) % len(r.items)
+
+	return d
+}
+
+// List returns the stored deliveries, oldest first.
+func (r *Ring) List() []Delivery {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	list := make([]Delivery, 0, len(r.items))
+	list = append(list, r.items[r.start:]...)
+	list = append(list, r.items[:r.start]...)
+
+	return list
+}
+
+// Get returns the delivery with the given ID if it is still stored.
+func (r *Ring) Get(id int) (Delivery, bool) {
+	for _, d := range r.List() {
+		if d.ID == id {
+			return d, true
+		}
+	}
+
+	return Delivery{}, false
+}
+
+// Server accepts deliveries on every path but InspectPath, which lists the
+// recorded deliveries as JSON. When Secret is set, deliveries with a missing
+// or invalid signature are recorded but answered with 401.
+type Server struct {
+	Secret string
+	Ring   *Ring
+	// Out receives a human readable dump of every delivery.
+	Out io.Writer
+
+	mu sync.Mutex
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == InspectPath || strings.HasPrefix(r.URL.Path, InspectPath+"/") {
+		s.serveInspect(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d := Delivery{
+		ReceivedAt: time.Now(),
+		Method:     r.Method,
+		Path:       r.URL.RequestURI(),
+		Headers:    r.Header.Clone(),
+	}
+
+	if json.Valid(body) {
+		d.Body = body
+	} else {
+		d.RawBody = string(body)
+	}
+
+	if s.Secret != "" {
+		verifier := &signature.Verifier{Secret: s.Secret}
+		err := verifier.Verify(r.Header.Get(signature.HeaderSignature), r.Header.Get(signature.HeaderTimestamp), body)
+		verified := err == nil
+		d.Verified = &verified
+
+		if err != nil {
+			d.VerifyError = err.Error()
+		}
+	}
+
+	d = s.Ring.Add(d)
+	s.print(d, body)
+
+	if d.Verified != nil && !*d.Verified {
+		http.Error(w, d.VerifyError, http.StatusUnauthorized)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveInspect(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	var v interface{} = s.Ring.List()
+
+	if id := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, InspectPath), "/"); id != "" {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			http.Error(w, "invalid delivery id", http.StatusBadRequest)
+			return
+		}
+
+		d, ok := s.Ring.Get(n)
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		v = d
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func (s *Server) print(d Delivery, body []byte) {
+	if s.Out == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fmt.Fprintf(s.Out, "#%d %s %s %s\n", d.ID, d.ReceivedAt.Format(time.RFC3339), d.Method, d.Path)
+
+	switch {
+	case d.Verified == nil:
+	case *d.Verified:
+		fmt.Fprintln(s.Out, "Signature: valid")
+	default:
+		fmt.Fprintf(s.Out, "Signature: INVALID (%s)\n", d.VerifyError)
+	}
+
+	names := make([]string, 0, len(d.Headers))
+	for name := range d.Headers {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(s.Out, "  %s: %s\n", name, strings.Join(d.Headers[name], ", "))
+	}
+
+	if len(body) > 0 {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, body, "  ", "  "); err == nil {
+			body = pretty.Bytes()
+		}
+
+		fmt.Fprintf(s.Out, "\n  %s\n", body)
+	}
+
+	fmt.Fprintln(s.Out)
+}
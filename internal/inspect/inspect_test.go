@@ -0,0 +1,242 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/pkg/signature"
+)
+
+func TestRingEviction(t *testing.T) {
+	tests := []struct {
+		size int
+		adds int
+		want []int
+	}{
+		{size: 3, adds: 0, want: []int{}},
+		{size: 3, adds: 2, want: []int{1, 2}},
+		{size: 3, adds: 3, want: []int{1, 2, 3}},
+		{size: 3, adds: 4, want: []int{2, 3, 4}},
+		{size: 3, adds: 8, want: []int{6, 7, 8}},
+		{size: 0, adds: 2, want: []int{2}},
+	}
+
+	for _, tt := range tests {
+		r := NewRing(tt.size)
+
+		for i := 0; i < tt.adds; i++ {
+			r.Add(Delivery{})
+		}
+
+		got := []int{}
+		for _, d := range r.List() {
+			got = append(got, d.ID)
+		}
+
+		if !equalInts(got, tt.want) {
+			t.Errorf("ring of %d after %d adds holds %v, want %v", tt.size, tt.adds, got, tt.want)
+		}
+
+		if tt.adds > len(tt.want) {
+			if _, ok := r.Get(1); ok {
+				t.Errorf("ring of %d after %d adds still holds delivery 1", tt.size, tt.adds)
+			}
+		}
+	}
+}
+
+func TestServerSignatures(t *testing.T) {
+	const secret = "whsec_test"
+
+	body := []byte(`{"type":"order.created"}`)
+
+	tests := []struct {
+		name     string
+		secret   string
+		header   http.Header
+		status   int
+		verified *bool
+	}{
+		{
+			name:   "no secret",
+			header: http.Header{},
+			status: http.StatusNoContent,
+		},
+		{
+			name:     "valid signature",
+			secret:   secret,
+			header:   signature.Sign(secret, time.Now(), body),
+			status:   http.StatusNoContent,
+			verified: boolPtr(true),
+		},
+		{
+			name:     "wrong secret",
+			secret:   secret,
+			header:   signature.Sign("whsec_other", time.Now(), body),
+			status:   http.StatusUnauthorized,
+			verified: boolPtr(false),
+		},
+		{
+			name:     "stale timestamp",
+			secret:   secret,
+			header:   signature.Sign(secret, time.Now().Add(-time.Hour), body),
+			status:   http.StatusUnauthorized,
+			verified: boolPtr(false),
+		},
+		{
+			name:     "missing signature",
+			secret:   secret,
+			header:   http.Header{},
+			status:   http.StatusUnauthorized,
+			verified: boolPtr(false),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			s := &Server{Secret: tt.secret, Ring: NewRing(10), Out: &out}
+
+			req := httptest.NewRequest(http.MethodPost, "/hooks/orders", bytes.NewReader(body))
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, req)
+
+			if rec.Code != tt.status {
+				t.Errorf("got status %d, want %d", rec.Code, tt.status)
+			}
+
+			// Rejected deliveries are recorded all the same.
+			list := s.Ring.List()
+			if len(list) != 1 {
+				t.Fatalf("recorded %d deliveries, want 1", len(list))
+			}
+
+			d := list[0]
+
+			switch {
+			case tt.verified == nil && d.Verified != nil:
+				t.Errorf("got verified %t, want no verification", *d.Verified)
+			case tt.verified != nil && (d.Verified == nil || *d.Verified != *tt.verified):
+				t.Errorf("got verified %v, want %t", d.Verified, *tt.verified)
+			}
+
+			if tt.verified != nil && !*tt.verified && d.VerifyError == "" {
+				t.Error("got no verification error")
+			}
+
+			if d.Path != "/hooks/orders" || string(d.Body) != string(body) {
+				t.Errorf("recorded %s %s, want /hooks/orders %s", d.Path, d.Body, body)
+			}
+
+			if tt.verified != nil && !*tt.verified && !strings.Contains(out.String(), "Signature: INVALID") {
+				t.Errorf("dump %q does not flag the invalid signature", out.String())
+			}
+		})
+	}
+}
+
+func TestServerInspect(t *testing.T) {
+	s := &Server{Ring: NewRing(2)}
+
+	for _, body := range []string{`{"n":1}`, "not json", `{"n":3}`} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/hook?x=1", strings.NewReader(body)))
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, InspectPath, nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("got content type %q, want application/json", ct)
+	}
+
+	var list []Delivery
+	if err := json.Unmarshal(rec.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(list) != 2 || list[0].ID != 2 || list[1].ID != 3 {
+		t.Fatalf("listed %+v, want deliveries 2 and 3", list)
+	}
+
+	if list[0].RawBody != "not json" || list[0].Body != nil {
+		t.Errorf("delivery 2 has body %s and raw body %q, want only the raw body", list[0].Body, list[0].RawBody)
+	}
+
+	var body bytes.Buffer
+	if err := json.Compact(&body, list[1].Body); err != nil {
+		t.Fatal(err)
+	}
+
+	if list[1].Path != "/hook?x=1" || body.String() != `{"n":3}` {
+		t.Errorf("delivery 3 is %s %s, want /hook?x=1 {\"n\":3}", list[1].Path, body.String())
+	}
+
+	tests := []struct {
+		method string
+		path   string
+		status int
+	}{
+		{method: http.MethodGet, path: InspectPath + "/3", status: http.StatusOK},
+		{method: http.MethodGet, path: InspectPath + "/1", status: http.StatusNotFound},
+		{method: http.MethodGet, path: InspectPath + "/x", status: http.StatusBadRequest},
+		{method: http.MethodPost, path: InspectPath, status: http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: got status %d, want %d", tt.method, tt.path, rec.Code, tt.status)
+		}
+	}
+
+	if got := len(s.Ring.List()); got != 2 {
+		t.Errorf("inspecting recorded deliveries, now %d, want 2", got)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,114 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package manifest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// SecretMode selects how exported manifests carry webhook secrets.
+type SecretMode string
+
+const (
+	// SecretsOmit leaves secrets out, so applying keeps the live ones.
+	SecretsOmit SecretMode = "omit"
+	// SecretsEnv replaces secrets with environment variable references
+	// named after the webhook URL, such as $API_EXAMPLE_COM_HOOKS_SECRET.
+	SecretsEnv SecretMode = "env"
+	// SecretsInclude writes secrets in plain text.
+	SecretsInclude SecretMode = "include"
+)
+
+// SecretModes lists the accepted secret modes.
+var SecretModes = []SecretMode{SecretsOmit, SecretsEnv, SecretsInclude}
+
+// FromState builds a manifest describing s. Resources are sorted by their
+// key so exports of the same account are identical and diff cleanly.
+// Subscriptions to webhooks missing from s are left out.
+func FromState(s *State, secrets SecretMode) (*Manifest, error) {
+	m := &Manifest{}
+
+	for _, et := range s.EventTypes {
+		e := EventType{Name: et.Name, Description: et.Description}
+
+		if len(et.Schema) > 0 && string(et.Schema) != "null" {
+			if err := json.Unmarshal(et.Schema, &e.Schema); err != nil {
+				return nil, fmt.Errorf("event type %s: decoding schema: %w", et.Name, err)
+			}
+		}
+
+		m.EventTypes = append(m.EventTypes, e)
+	}
+
+	for _, wh := range s.Webhooks {
+		w := Webhook{URL: wh.URL, Description: wh.Description, Disabled: wh.Disabled}
+
+		switch secrets {
+		case SecretsEnv:
+			w.Secret = "$" + SecretEnvName(wh.URL)
+		case SecretsInclude:
+			w.Secret = wh.Secret
+		}
+
+		m.Webhooks = append(m.Webhooks, w)
+	}
+
+	urls := s.webhookURLs()
+
+	for _, sub := range s.Subscriptions {
+		if u, ok := urls[sub.WebhookID]; ok {
+			m.Subscriptions = append(m.Subscriptions, Subscription{Webhook: u, EventType: sub.EventType})
+		}
+	}
+
+	sort.Slice(m.EventTypes, func(i, j int) bool { return m.EventTypes[i].Name < m.EventTypes[j].Name })
+	sort.Slice(m.Webhooks, func(i, j int) bool { return m.Webhooks[i].URL < m.Webhooks[j].URL })
+	sort.Slice(m.Subscriptions, func(i, j int) bool { return m.Subscriptions[i].key() < m.Subscriptions[j].key() })
+
+	return m, nil
+}
+
+// SecretEnvName derives the environment variable holding the secret of the
+// webhook with the given URL from its host and path.
+func SecretEnvName(webhookURL string) string {
+	name := webhookURL
+	if u, err := url.Parse(webhookURL); err == nil && u.Host != "" {
+		name = u.Host + u.Path
+	}
+
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+
+		return '_'
+	}, name)
+
+	name = strings.Trim(name, "_")
+	if name == "" || (name[0] >= '0' && name[0] <= '9') {
+		name = "WEBHOOK_" + name
+	}
+
+	return name + "_SECRET"
+}
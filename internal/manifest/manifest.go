@@ -0,0 +1,212 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package manifest describes the configuration of an account, its event
+// types, webhooks and subscriptions, as a YAML document, and converges an
+// account towards it.
+package manifest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Manifest is the desired configuration of an account. Webhooks are
+// identified by their URL and event types by their name, so a manifest
+// carries no IDs and can be applied to any account.
+type Manifest struct {
+	EventTypes    []EventType    `yaml:"event_types,omitempty" json:"event_types,omitempty"`
+	Webhooks      []Webhook      `yaml:"webhooks,omitempty" json:"webhooks,omitempty"`
+	Subscriptions []Subscription `yaml:"subscriptions,omitempty" json:"subscriptions,omitempty"`
+}
+
+// EventType is an event type. A nil schema leaves the live schema as is.
+type EventType struct {
+	Name        string      `yaml:"name" json:"name"`
+	Description string      `yaml:"description,omitempty" json:"description,omitempty"`
+	Schema      interface{} `yaml:"schema,omitempty" json:"schema,omitempty"`
+}
+
+// Webhook is a webhook endpoint. Its secret may reference environment
+// variables, as in "$ORDERS_WEBHOOK_SECRET", so it need not be committed; an
+// empty secret leaves the live secret as is.
+type Webhook struct {
+	URL         string `yaml:"url" json:"url"`
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+	Secret      string `yaml:"secret,omitempty" json:"secret,omitempty"`
+	Disabled    bool   `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+}
+
+// Subscription subscribes the webhook with the given URL to an event type
+// or pattern.
+type Subscription struct {
+	Webhook   string `yaml:"webhook" json:"webhook"`
+	EventType string `yaml:"event_type" json:"event_type"`
+}
+
+func (s Subscription) key() string {
+	return s.Webhook + " " + s.EventType
+}
+
+func (s Subscription) String() string {
+	return s.EventType + " -> " + s.Webhook
+}
+
+// Parse decodes and validates a manifest, expanding environment variables
+// in webhook secrets.
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+
+	if err := dec.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("parsing manifest: %w", err)
+	}
+
+	for i := range m.Webhooks {
+		secret, err := expandEnv(m.Webhooks[i].Secret)
+		if err != nil {
+			return nil, fmt.Errorf("webhook %s: %w", m.Webhooks[i].URL, err)
+		}
+
+		m.Webhooks[i].Secret = secret
+	}
+
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &m, nil
+}
+
+// Validate reports missing fields, duplicates and subscriptions to webhooks
+// the manifest does not declare.
+func (m *Manifest) Validate() error {
+	var problems []string
+
+	eventTypes := map[string]bool{}
+
+	for i, et := range m.EventTypes {
+		switch {
+		case et.Name == "":
+			problems = append(problems, fmt.Sprintf("event_types[%d]: name is required", i))
+		case eventTypes[et.Name]:
+			problems = append(problems, fmt.Sprintf("event_types[%d]: duplicate event type %s", i, et.Name))
+		}
+
+		if _, err := json.Marshal(et.Schema); err != nil {
+			problems = append(problems, fmt.Sprintf("event_types[%d]: schema: %v", i, err))
+		}
+
+		eventTypes[et.Name] = true
+	}
+
+	webhooks := map[string]bool{}
+
+	for i, wh := range m.Webhooks {
+		switch {
+		case wh.URL == "":
+			problems = append(problems, fmt.Sprintf("webhooks[%d]: url is required", i))
+		case webhooks[wh.URL]:
+			problems = append(problems, fmt.Sprintf("webhooks[%d]: duplicate webhook %s", i, wh.URL))
+		}
+
+		webhooks[wh.URL] = true
+	}
+
+	subscriptions := map[string]bool{}
+
+	for i, sub := range m.Subscriptions {
+		switch {
+		case sub.Webhook == "" || sub.EventType == "":
+			problems = append(problems, fmt.Sprintf("subscriptions[%d]: webhook and event_type are required", i))
+		case !webhooks[sub.Webhook]:
+			problems = append(problems, fmt.Sprintf("subscriptions[%d]: webhook %s is not declared in webhooks", i, sub.Webhook))
+		case subscriptions[sub.key()]:
+			problems = append(problems, fmt.Sprintf("subscriptions[%d]: duplicate subscription", i))
+		}
+
+		subscriptions[sub.key()] = true
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid manifest:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	return nil
+}
+
+// Encode writes the manifest as YAML.
+func (m *Manifest) Encode(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+
+	if err := enc.Encode(m); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+// expandEnv expands $VAR and ${VAR} references, failing on unset variables
+// rather than silently emptying the value.
+func expandEnv(s string) (string, error) {
+	var missing []string
+
+	expanded := os.Expand(s, func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s is not set", strings.Join(missing, ", "))
+	}
+
+	return expanded, nil
+}
+
+// canonicalJSON re-encodes a JSON document with sorted keys and no
+// insignificant whitespace, so two documents compare equal when they hold
+// the same value.
+func canonicalJSON(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
@@ -0,0 +1,418 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/schema"
+)
+
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionUpdate Action = "update"
+	ActionDelete Action = "delete"
+)
+
+type Kind string
+
+const (
+	KindEventType    Kind = "event_type"
+	KindWebhook      Kind = "webhook"
+	KindSubscription Kind = "subscription"
+)
+
+// Sensitive replaces secrets in field changes.
+const Sensitive = "(sensitive)"
+
+// FieldChange is a field whose live value differs from the manifest.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Change is one operation needed to converge an account to a manifest.
+type Change struct {
+	Action Action        `json:"action"`
+	Kind   Kind          `json:"kind"`
+	Name   string        `json:"name"`
+	ID     string        `json:"id,omitempty"`
+	Fields []FieldChange `json:"fields,omitempty"`
+
+	eventType    *EventType
+	webhook      *Webhook
+	subscription *Subscription
+
+	// liveSchema is the schema an event type update replaces.
+	liveSchema json.RawMessage
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s %s", c.Kind, c.Name)
+}
+
+// SchemaChanges classifies the changes an event type update makes to its
+// schema. It returns nil for other changes, and for updates leaving the
+// schema as is.
+func (c Change) SchemaChanges() ([]schema.Change, error) {
+	if c.Kind != KindEventType || c.Action != ActionUpdate || c.eventType.Schema == nil {
+		return nil, nil
+	}
+
+	raw, err := json.Marshal(c.eventType.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	newSchema, err := schema.Compile(raw)
+	if err != nil {
+		return nil, fmt.Errorf("event type %s: %w", c.Name, err)
+	}
+
+	var old *schema.Schema
+
+	if len(c.liveSchema) > 0 && string(c.liveSchema) != "null" {
+		if old, err = schema.Compile(c.liveSchema); err != nil {
+			return nil, fmt.Errorf("event type %s: current schema: %w", c.Name, err)
+		}
+	}
+
+	return schema.Compare(old, newSchema), nil
+}
+
+// BreakingSchemaError is returned by Apply when an event type update would
+// make breaking changes to its schema and they are not allowed.
+type BreakingSchemaError struct {
+	EventType string
+	Changes   []schema.Change
+}
+
+func (e *BreakingSchemaError) Error() string {
+	return fmt.Sprintf("event type %s: the schema change is breaking (breaking changes: %d)", e.EventType, len(e.Changes))
+}
+
+// Plan is the ordered list of changes converging an account to a manifest.
+// Creates and updates come first, event types before the webhooks and
+// subscriptions that may depend on them, then deletes in reverse order.
+type Plan struct {
+	Changes []Change `json:"changes"`
+
+	// AllowBreaking lets Apply make breaking changes to the schemas of
+	// event types.
+	AllowBreaking bool `json:"-"`
+
+	webhookIDs map[string]string
+}
+
+// Empty reports whether the account already matches the manifest.
+func (p *Plan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+// Count returns the number of changes with the given action.
+func (p *Plan) Count(action Action) int {
+	n := 0
+
+	for _, c := range p.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Compute plans the changes converging s to m. Live resources missing from
+// the manifest are only deleted when prune is set.
+func Compute(m *Manifest, s *State, prune bool) (*Plan, error) {
+	p := &Plan{Changes: []Change{}, webhookIDs: map[string]string{}}
+
+	var deletes []Change
+
+	liveEventTypes := make(map[string]api.EventType, len(s.EventTypes))
+	for _, et := range s.EventTypes {
+		liveEventTypes[et.Name] = et
+	}
+
+	declared := map[string]bool{}
+
+	for i := range m.EventTypes {
+		et := &m.EventTypes[i]
+		declared[et.Name] = true
+
+		live, ok := liveEventTypes[et.Name]
+		if !ok {
+			p.Changes = append(p.Changes, Change{Action: ActionCreate, Kind: KindEventType, Name: et.Name, eventType: et})
+			continue
+		}
+
+		fields, err := diffEventType(et, &live)
+		if err != nil {
+			return nil, fmt.Errorf("event type %s: %w", et.Name, err)
+		}
+
+		if len(fields) > 0 {
+			p.Changes = append(p.Changes, Change{Action: ActionUpdate, Kind: KindEventType, Name: et.Name, ID: live.ID, Fields: fields, eventType: et, liveSchema: live.Schema})
+		}
+	}
+
+	for _, et := range s.EventTypes {
+		if !declared[et.Name] {
+			deletes = append(deletes, Change{Action: ActionDelete, Kind: KindEventType, Name: et.Name, ID: et.ID})
+		}
+	}
+
+	liveWebhooks := make(map[string]api.Webhook, len(s.Webhooks))
+	for _, wh := range s.Webhooks {
+		liveWebhooks[wh.URL] = wh
+		p.webhookIDs[wh.URL] = wh.ID
+	}
+
+	declared = map[string]bool{}
+
+	for i := range m.Webhooks {
+		wh := &m.Webhooks[i]
+		declared[wh.URL] = true
+
+		live, ok := liveWebhooks[wh.URL]
+		if !ok {
+			p.Changes = append(p.Changes, Change{Action: ActionCreate, Kind: KindWebhook, Name: wh.URL, webhook: wh})
+			continue
+		}
+
+		if fields := diffWebhook(wh, &live); len(fields) > 0 {
+			p.Changes = append(p.Changes, Change{Action: ActionUpdate, Kind: KindWebhook, Name: wh.URL, ID: live.ID, Fields: fields, webhook: wh})
+		}
+	}
+
+	for _, wh := range s.Webhooks {
+		if !declared[wh.URL] {
+			deletes = append(deletes, Change{Action: ActionDelete, Kind: KindWebhook, Name: wh.URL, ID: wh.ID})
+		}
+	}
+
+	urls := s.webhookURLs()
+	liveSubscriptions := make(map[string]bool, len(s.Subscriptions))
+
+	for _, sub := range s.Subscriptions {
+		liveSubscriptions[Subscription{Webhook: urls[sub.WebhookID], EventType: sub.EventType}.key()] = true
+	}
+
+	declared = map[string]bool{}
+
+	for i := range m.Subscriptions {
+		sub := &m.Subscriptions[i]
+		declared[sub.key()] = true
+
+		if !liveSubscriptions[sub.key()] {
+			p.Changes = append(p.Changes, Change{Action: ActionCreate, Kind: KindSubscription, Name: sub.String(), subscription: sub})
+		}
+	}
+
+	for _, sub := range s.Subscriptions {
+		webhook, ok := urls[sub.WebhookID]
+		if !ok {
+			webhook = sub.WebhookID
+		}
+
+		s := Subscription{Webhook: webhook, EventType: sub.EventType}
+		if !declared[s.key()] {
+			deletes = append(deletes, Change{Action: ActionDelete, Kind: KindSubscription, Name: s.String(), ID: sub.ID})
+		}
+	}
+
+	if prune {
+		for i := len(deletes) - 1; i >= 0; i-- {
+			p.Changes = append(p.Changes, deletes[i])
+		}
+	}
+
+	return p, nil
+}
+
+func diffEventType(et *EventType, live *api.EventType) ([]FieldChange, error) {
+	var fields []FieldChange
+
+	if et.Description != live.Description {
+		fields = append(fields, FieldChange{Field: "description", Old: live.Description, New: et.Description})
+	}
+
+	if et.Schema != nil {
+		raw, err := json.Marshal(et.Schema)
+		if err != nil {
+			return nil, err
+		}
+
+		want, err := canonicalJSON(raw)
+		if err != nil {
+			return nil, err
+		}
+
+		have, err := canonicalJSON(live.Schema)
+		if err != nil {
+			return nil, err
+		}
+
+		if want != have {
+			fields = append(fields, FieldChange{Field: "schema", Old: have, New: want})
+		}
+	}
+
+	return fields, nil
+}
+
+func diffWebhook(wh *Webhook, live *api.Webhook) []FieldChange {
+	var fields []FieldChange
+
+	if wh.Description != live.Description {
+		fields = append(fields, FieldChange{Field: "description", Old: live.Description, New: wh.Description})
+	}
+
+	if wh.Disabled != live.Disabled {
+		fields = append(fields, FieldChange{Field: "disabled", Old: strconv.FormatBool(live.Disabled), New: strconv.FormatBool(wh.Disabled)})
+	}
+
+	if wh.Secret != "" && wh.Secret != live.Secret {
+		fields = append(fields, FieldChange{Field: "secret", Old: Sensitive, New: Sensitive})
+	}
+
+	return fields
+}
+
+// Apply performs the changes in order, calling fn after each one succeeds.
+// It stops at the first failure. Unless AllowBreaking is set, nothing is
+// changed when an event type update would break its schema, see
+// BreakingSchemaError.
+func (p *Plan) Apply(ctx context.Context, client *api.Client, fn func(Change)) error {
+	if !p.AllowBreaking {
+		for _, c := range p.Changes {
+			changes, err := c.SchemaChanges()
+			if err != nil {
+				return err
+			}
+
+			if breaking := schema.Breaking(changes); len(breaking) > 0 {
+				return &BreakingSchemaError{EventType: c.Name, Changes: breaking}
+			}
+		}
+	}
+
+	for _, c := range p.Changes {
+		if err := p.apply(ctx, client, &c); err != nil {
+			return fmt.Errorf("%s %s: %w", c.Action, c, err)
+		}
+
+		if fn != nil {
+			fn(c)
+		}
+	}
+
+	return nil
+}
+
+func (p *Plan) apply(ctx context.Context, client *api.Client, c *Change) error {
+	switch c.Action {
+	case ActionCreate, ActionUpdate:
+		return p.put(ctx, client, c)
+	case ActionDelete:
+		switch c.Kind {
+		case KindEventType:
+			return client.DeleteEventType(ctx, c.ID)
+		case KindWebhook:
+			return client.DeleteWebhook(ctx, c.ID)
+		case KindSubscription:
+			return client.DeleteSubscription(ctx, c.ID)
+		}
+	}
+
+	return fmt.Errorf("unsupported change %s", c.Action)
+}
+
+func (p *Plan) put(ctx context.Context, client *api.Client, c *Change) error {
+	switch c.Kind {
+	case KindEventType:
+		params := api.EventTypeParams{Description: c.eventType.Description}
+
+		if c.eventType.Schema != nil {
+			schema, err := json.Marshal(c.eventType.Schema)
+			if err != nil {
+				return err
+			}
+
+			params.Schema = schema
+		}
+
+		if c.Action == ActionUpdate {
+			_, err := client.UpdateEventType(ctx, c.ID, params)
+			return err
+		}
+
+		params.Name = c.eventType.Name
+
+		et, err := client.CreateEventType(ctx, params)
+		if err == nil {
+			c.ID = et.ID
+		}
+
+		return err
+	case KindWebhook:
+		params := api.WebhookParams{
+			Description: c.webhook.Description,
+			Secret:      c.webhook.Secret,
+			Disabled:    c.webhook.Disabled,
+		}
+
+		if c.Action == ActionUpdate {
+			_, err := client.UpdateWebhook(ctx, c.ID, params)
+			return err
+		}
+
+		params.URL = c.webhook.URL
+
+		wh, err := client.CreateWebhook(ctx, params)
+		if err == nil {
+			c.ID = wh.ID
+			p.webhookIDs[wh.URL] = wh.ID
+		}
+
+		return err
+	case KindSubscription:
+		webhookID, ok := p.webhookIDs[c.subscription.Webhook]
+		if !ok {
+			return fmt.Errorf("webhook %s does not exist", c.subscription.Webhook)
+		}
+
+		sub, err := client.CreateSubscription(ctx, api.SubscriptionParams{
+			WebhookID: webhookID,
+			EventType: c.subscription.EventType,
+		})
+		if err == nil {
+			c.ID = sub.ID
+		}
+
+		return err
+	}
+
+	return fmt.Errorf("unsupported resource %s", c.Kind)
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package manifest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+func TestApplyBreakingSchemaChanges(t *testing.T) {
+	live := &State{EventTypes: []api.EventType{{
+		ID:     "evt_type_1",
+		Name:   "order.created",
+		Schema: json.RawMessage(`{"type":"object","properties":{"id":{"type":"string"}}}`),
+	}}}
+
+	tests := []struct {
+		name     string
+		schema   string
+		breaking int
+	}{
+		{
+			name:   "new optional field",
+			schema: `{"type":"object","properties":{"id":{"type":"string"},"total":{"type":"number"}}}`,
+		},
+		{
+			name:     "removed field",
+			schema:   `{"type":"object","properties":{}}`,
+			breaking: 1,
+		},
+		{
+			name:     "new required field and type change",
+			schema:   `{"type":"object","properties":{"id":{"type":"integer"}},"required":["id"]}`,
+			breaking: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse([]byte(`{"event_types":[{"name":"order.created","schema":` + tt.schema + `}]}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			plan, err := Compute(m, live, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(plan.Changes) != 1 || plan.Changes[0].Action != ActionUpdate {
+				t.Fatalf("got changes %+v, want one update", plan.Changes)
+			}
+
+			if tt.breaking == 0 {
+				changes, err := plan.Changes[0].SchemaChanges()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if len(changes) == 0 {
+					t.Error("got no schema changes")
+				}
+
+				return
+			}
+
+			// The refusal comes before any request, so no client is needed.
+			var schemaErr *BreakingSchemaError
+
+			err = plan.Apply(context.Background(), nil, nil)
+			if !errors.As(err, &schemaErr) {
+				t.Fatalf("got %v, want a breaking schema error", err)
+			}
+
+			if schemaErr.EventType != "order.created" || len(schemaErr.Changes) != tt.breaking {
+				t.Errorf("got %s with %d breaking changes, want order.created with %d", schemaErr.EventType, len(schemaErr.Changes), tt.breaking)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package manifest
+
+import (
+	"context"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+// State is the live configuration of an account.
+type State struct {
+	EventTypes    []api.EventType
+	Webhooks      []api.Webhook
+	Subscriptions []api.Subscription
+}
+
+// Fetch pages through every event type, webhook and subscription of the
+// account.
+func Fetch(ctx context.Context, client *api.Client) (*State, error) {
+	var s State
+
+	opts := api.ListOptions{PerPage: api.DefaultPerPage}
+
+	for opts.Page = 1; ; opts.Page++ {
+		eventTypes, p, err := client.ListEventTypes(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		s.EventTypes = append(s.EventTypes, eventTypes...)
+
+		if !p.HasNext() {
+			break
+		}
+	}
+
+	for opts.Page = 1; ; opts.Page++ {
+		webhooks, p, err := client.ListWebhooks(ctx, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Webhooks = append(s.Webhooks, webhooks...)
+
+		if !p.HasNext() {
+			break
+		}
+	}
+
+	subOpts := api.SubscriptionListOptions{ListOptions: opts}
+
+	for subOpts.Page = 1; ; subOpts.Page++ {
+		subscriptions, p, err := client.ListSubscriptions(ctx, subOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		s.Subscriptions = append(s.Subscriptions, subscriptions...)
+
+		if !p.HasNext() {
+			break
+		}
+	}
+
+	return &s, nil
+}
+
+// webhookURLs maps the ID of every webhook to its URL.
+func (s *State) webhookURLs() map[string]string {
+	urls := make(map[string]string, len(s.Webhooks))
+	for _, wh := range s.Webhooks {
+		urls[wh.ID] = wh.URL
+	}
+
+	return urls
+}
@@ -0,0 +1,348 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"io"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/delivery"
+)
+
+const (
+	targetPending   = "pending"
+	responseSnippet = 1024
+)
+
+// dispatch starts delivering ev to every enabled webhook subscribed to its
+// type. It must be called with the store lock held.
+func (a *account) dispatch(ev *api.Event) {
+	st := a.store
+	targets := map[string]string{}
+
+	for _, sub := range st.Subscriptions {
+		if !matchEventType(sub.EventType, ev.Type) {
+			continue
+		}
+
+		if _, wh := st.webhook(sub.WebhookID); wh != nil && !wh.Disabled {
+			targets[wh.ID] = targetPending
+		}
+	}
+
+	a.targets[ev.ID] = targets
+	a.publishDelivery(ev)
+
+	if len(targets) == 0 {
+		ev.Status = api.EventStatusDelivered
+		return
+	}
+
+	for webhookID := range targets {
+		a.wg.Add(1)
+
+		go a.deliver(ev.ID, webhookID, 0)
+	}
+}
+
+// redeliver starts delivering ev again to a single webhook, keeping the
+// outcome of its deliveries to the other webhooks. It must be called with the
+// store lock held.
+func (a *account) redeliver(ev *api.Event, webhookID string) {
+	targets := a.targets[ev.ID]
+	if targets == nil {
+		targets = map[string]string{}
+		a.targets[ev.ID] = targets
+	}
+
+	targets[webhookID] = targetPending
+	ev.Status = settle(targets)
+	ev.UpdatedAt = time.Now().UTC()
+
+	a.wg.Add(1)
+
+	go a.deliver(ev.ID, webhookID, 0)
+}
+
+// resume restarts the deliveries of the events loaded from the data file:
+// scheduled events are scheduled again and pending events are delivered to
+// the webhooks they have not reached yet, which are worked out from the
+// recorded attempts.
+func (a *account) resume() {
+	st := a.store
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	resumed := false
+
+	for _, ev := range st.Events {
+		switch {
+		case ev.Status == api.EventStatusScheduled && ev.DeliverAt != nil:
+			a.schedule(ev)
+		case ev.Status == api.EventStatusPending:
+			a.resumeDelivery(ev)
+			resumed = true
+		}
+	}
+
+	if !resumed {
+		return
+	}
+
+	if err := st.save(); err != nil {
+		a.logf("saving state: %v", err)
+	}
+}
+
+// resumeDelivery delivers a pending event to the webhooks it is still to
+// reach. It must be called with the store lock held.
+func (a *account) resumeDelivery(ev *api.Event) {
+	st := a.store
+	targets := map[string]string{}
+
+	for _, sub := range st.Subscriptions {
+		if !matchEventType(sub.EventType, ev.Type) {
+			continue
+		}
+
+		if _, wh := st.webhook(sub.WebhookID); wh != nil && !wh.Disabled {
+			targets[wh.ID] = targetPending
+		}
+	}
+
+	made := map[string]int{}
+
+	for _, attempt := range st.Attempts {
+		if attempt.EventID != ev.ID {
+			continue
+		}
+
+		if _, ok := targets[attempt.WebhookID]; !ok {
+			continue
+		}
+
+		made[attempt.WebhookID]++
+
+		switch {
+		case attempt.Status == api.AttemptStatusSucceeded:
+			targets[attempt.WebhookID] = api.AttemptStatusSucceeded
+		case attempt.NextRetryAt == nil:
+			targets[attempt.WebhookID] = api.AttemptStatusFailed
+		default:
+			targets[attempt.WebhookID] = targetPending
+		}
+	}
+
+	a.targets[ev.ID] = targets
+	ev.Status = settle(targets)
+	ev.UpdatedAt = time.Now().UTC()
+
+	for webhookID, outcome := range targets {
+		if outcome != targetPending {
+			continue
+		}
+
+		a.wg.Add(1)
+
+		go a.deliver(ev.ID, webhookID, made[webhookID])
+	}
+}
+
+// schedule dispatches a scheduled event once its delivery time comes, unless
+// it is cancelled first. It must be called with the store lock held.
+func (a *account) schedule(ev *api.Event) {
+	eventID, at := ev.ID, *ev.DeliverAt
+
+	a.wg.Add(1)
+
+	go func() {
+		defer a.wg.Done()
+
+		timer := time.NewTimer(time.Until(at))
+		defer timer.Stop()
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		st := a.store
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		_, ev := st.event(eventID)
+		if ev == nil || ev.Status != api.EventStatusScheduled {
+			return
+		}
+
+		ev.Status = api.EventStatusPending
+		ev.UpdatedAt = time.Now().UTC()
+		a.dispatch(ev)
+
+		if err := st.save(); err != nil {
+			a.logf("saving state: %v", err)
+		}
+	}()
+}
+
+// deliver attempts to deliver an event to a webhook until it succeeds, the
+// retries are exhausted, the event is cancelled or the server is closed.
+// made is the number of attempts already made.
+func (a *account) deliver(eventID, webhookID string, made int) {
+	defer a.wg.Done()
+
+	for n := made; ; n++ {
+		d, ok := a.prepare(eventID, webhookID)
+		if !ok {
+			return
+		}
+
+		attempt := a.attempt(d)
+		attempt.WebhookID = webhookID
+
+		var delay time.Duration
+		if attempt.Status == api.AttemptStatusFailed && n < len(a.retryDelays) {
+			delay = a.retryDelays[n]
+			next := attempt.CreatedAt.Add(delay)
+			attempt.NextRetryAt = &next
+		}
+
+		a.record(attempt, delay > 0)
+
+		if delay == 0 {
+			return
+		}
+
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// prepare snapshots what is needed to deliver an event, reporting false
+// when the delivery should no longer happen.
+func (a *account) prepare(eventID, webhookID string) (*delivery.Delivery, bool) {
+	st := a.store
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	_, ev := st.event(eventID)
+	_, wh := st.webhook(webhookID)
+
+	if ev == nil || wh == nil || ev.Status == api.EventStatusCancelled || a.ctx.Err() != nil {
+		return nil, false
+	}
+
+	return &delivery.Delivery{
+		ID:     st.nextID("dlv"),
+		URL:    wh.URL,
+		Secret: wh.Secret,
+		Payload: delivery.Payload{
+			ID:        ev.ID,
+			Type:      ev.Type,
+			CreatedAt: ev.CreatedAt,
+			Data:      ev.Data,
+		},
+	}, true
+}
+
+func (a *account) attempt(d *delivery.Delivery) *api.Attempt {
+	now := time.Now().UTC()
+
+	attempt := &api.Attempt{
+		ID:        d.ID,
+		EventID:   d.Payload.ID,
+		EventType: d.Payload.Type,
+		URL:       d.URL,
+		Status:    api.AttemptStatusFailed,
+		CreatedAt: now,
+	}
+
+	req, err := delivery.NewRequest(a.ctx, d, now)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	resp, err := a.client.Do(req)
+	attempt.DurationMS = time.Since(now).Milliseconds()
+
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, responseSnippet))
+	attempt.ResponseStatus = resp.StatusCode
+	attempt.ResponseBody = string(body)
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		attempt.Status = api.AttemptStatusSucceeded
+	}
+
+	return attempt
+}
+
+// record stores an attempt and settles the status of its event.
+func (a *account) record(attempt *api.Attempt, retrying bool) {
+	st := a.store
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	st.Attempts = append(st.Attempts, attempt)
+
+	a.logf("deliver %s %s -> %s %s %d %s", attempt.EventID, attempt.EventType, attempt.WebhookID, attempt.URL, attempt.ResponseStatus, attempt.Status)
+
+	_, ev := st.event(attempt.EventID)
+	targets := a.targets[attempt.EventID]
+
+	if ev != nil && targets != nil && !retrying && ev.Status != api.EventStatusCancelled {
+		targets[attempt.WebhookID] = attempt.Status
+		ev.Status = settle(targets)
+		ev.UpdatedAt = time.Now().UTC()
+	}
+
+	if err := st.save(); err != nil {
+		a.logf("saving state: %v", err)
+	}
+}
+
+// settle derives the status of an event from the outcome of its deliveries.
+func settle(targets map[string]string) string {
+	status := api.EventStatusDelivered
+
+	for _, outcome := range targets {
+		switch outcome {
+		case api.AttemptStatusFailed:
+			return api.EventStatusFailed
+		case targetPending:
+			status = api.EventStatusPending
+		}
+	}
+
+	return status
+}
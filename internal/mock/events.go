@@ -0,0 +1,401 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+func (a *account) serveEvents(w http.ResponseWriter, r *http.Request, rest []string) {
+	st := a.store
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		filter, ok := parseEventFilter(w, r)
+		if !ok {
+			return
+		}
+
+		st.mu.Lock()
+		items := make([]api.Event, 0, len(st.Events))
+		for _, ev := range st.Events {
+			if filter.match(st, ev) {
+				items = append(items, *ev)
+			}
+		}
+		st.mu.Unlock()
+
+		start, end, p := paginate(r, len(items))
+		writePage(w, items[start:end], p)
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		var params api.EventParams
+		if _, ok := readBody(w, r, &params); !ok {
+			return
+		}
+
+		if params.IdempotencyKey == "" {
+			params.IdempotencyKey = r.Header.Get(api.HeaderIdempotencyKey)
+		}
+
+		if details := validateEvent(params); len(details) > 0 {
+			writeValidationError(w, details...)
+			return
+		}
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		ev, created := a.createEvent(params)
+
+		status := http.StatusCreated
+		if !created {
+			status = http.StatusOK
+		}
+
+		a.commit(w, status, *ev)
+	case len(rest) == 1 && rest[0] == "batch" && r.Method == http.MethodPost:
+		var body struct {
+			Events []api.EventParams `json:"events"`
+		}
+
+		if _, ok := readBody(w, r, &body); !ok {
+			return
+		}
+
+		switch {
+		case len(body.Events) == 0:
+			writeValidationError(w, api.FieldError{Field: "events", Message: "is required"})
+			return
+		case len(body.Events) > api.MaxEventBatchSize:
+			writeValidationError(w, api.FieldError{
+				Field:   "events",
+				Message: fmt.Sprintf("must have at most %d items", api.MaxEventBatchSize),
+			})
+
+			return
+		}
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		results := make([]api.EventBatchResult, len(body.Events))
+
+		for i, params := range body.Events {
+			if details := validateEvent(params); len(details) > 0 {
+				results[i].Error = &api.Error{
+					StatusCode: http.StatusUnprocessableEntity,
+					Code:       "validation_failed",
+					Message:    "validation failed",
+					Details:    details,
+				}
+
+				continue
+			}
+
+			ev, _ := a.createEvent(params)
+			created := *ev
+			results[i].Event = &created
+		}
+
+		a.commit(w, http.StatusOK, results)
+	case len(rest) == 2 && rest[1] == "attempts":
+		a.serveAttempts(w, r, rest[0], "")
+	case len(rest) == 2 && r.Method == http.MethodPost:
+		var resend struct {
+			WebhookID string `json:"webhook_id"`
+		}
+
+		if rest[1] == "resend" && r.ContentLength != 0 {
+			if _, ok := readBody(w, r, &resend); !ok {
+				return
+			}
+		}
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		_, ev := st.event(rest[0])
+		if ev == nil {
+			writeNotFound(w, "event", rest[0])
+			return
+		}
+
+		switch rest[1] {
+		case "cancel":
+			if ev.Status != api.EventStatusPending && ev.Status != api.EventStatusScheduled {
+				writeError(w, http.StatusConflict, "conflict", "only pending or scheduled events can be cancelled")
+				return
+			}
+
+			ev.Status = api.EventStatusCancelled
+			ev.UpdatedAt = time.Now().UTC()
+		case "resend":
+			if resend.WebhookID == "" {
+				ev.Status = api.EventStatusPending
+				ev.UpdatedAt = time.Now().UTC()
+				a.dispatch(ev)
+
+				break
+			}
+
+			_, wh := st.webhook(resend.WebhookID)
+			if wh == nil {
+				writeNotFound(w, "webhook", resend.WebhookID)
+				return
+			}
+
+			if wh.Disabled {
+				writeError(w, http.StatusConflict, "conflict", "the webhook is disabled")
+				return
+			}
+
+			a.redeliver(ev, wh.ID)
+		default:
+			writeError(w, http.StatusNotFound, "not_found", "no such endpoint")
+			return
+		}
+
+		a.commit(w, http.StatusOK, *ev)
+	case len(rest) == 1:
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		i, ev := st.event(rest[0])
+		if ev == nil {
+			writeNotFound(w, "event", rest[0])
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, *ev)
+		case http.MethodDelete:
+			st.Events = append(st.Events[:i], st.Events[i+1:]...)
+			delete(a.targets, ev.ID)
+			a.commit(w, http.StatusNoContent, nil)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+type eventFilter struct {
+	eventType    string
+	status       string
+	webhookID    string
+	createdAfter time.Time
+	updatedAfter time.Time
+}
+
+func parseEventFilter(w http.ResponseWriter, r *http.Request) (eventFilter, bool) {
+	q := r.URL.Query()
+
+	f := eventFilter{
+		eventType: q.Get("type"),
+		status:    q.Get("status"),
+		webhookID: q.Get("webhook_id"),
+	}
+
+	if after := q.Get("created_after"); after != "" {
+		t, err := time.Parse(time.RFC3339, after)
+		if err != nil {
+			writeValidationError(w, api.FieldError{Field: "created_after", Message: "must be an RFC 3339 time"})
+			return f, false
+		}
+
+		f.createdAfter = t
+	}
+
+	if after := q.Get("updated_after"); after != "" {
+		t, err := time.Parse(time.RFC3339, after)
+		if err != nil {
+			writeValidationError(w, api.FieldError{Field: "updated_after", Message: "must be an RFC 3339 time"})
+			return f, false
+		}
+
+		f.updatedAfter = t
+	}
+
+	return f, true
+}
+
+// match must be called with the store lock held.
+func (f eventFilter) match(st *store, ev *api.Event) bool {
+	if f.eventType != "" && !matchEventType(f.eventType, ev.Type) {
+		return false
+	}
+
+	if f.status != "" && ev.Status != f.status {
+		return false
+	}
+
+	if !f.createdAfter.IsZero() && !ev.CreatedAt.After(f.createdAfter) {
+		return false
+	}
+
+	if !f.updatedAfter.IsZero() && !ev.UpdatedAt.After(f.updatedAfter) {
+		return false
+	}
+
+	if f.webhookID != "" {
+		for _, a := range st.Attempts {
+			if a.EventID == ev.ID && a.WebhookID == f.webhookID {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return true
+}
+
+// matchEventType reports whether an event type matches a pattern such as
+// "order.*".
+func matchEventType(pattern, eventType string) bool {
+	ok, err := path.Match(pattern, eventType)
+	return err == nil && ok
+}
+
+func (a *account) serveAttempts(w http.ResponseWriter, r *http.Request, eventID, webhookID string) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	q := r.URL.Query()
+	status, eventType := q.Get("status"), q.Get("event_type")
+
+	if webhookID == "" {
+		webhookID = q.Get("webhook_id")
+	}
+
+	var createdAfter time.Time
+	if after := q.Get("created_after"); after != "" {
+		t, err := time.Parse(time.RFC3339, after)
+		if err != nil {
+			writeValidationError(w, api.FieldError{Field: "created_after", Message: "must be an RFC 3339 time"})
+			return
+		}
+
+		createdAfter = t
+	}
+
+	st := a.store
+
+	st.mu.Lock()
+	if eventID != "" {
+		if _, ev := st.event(eventID); ev == nil {
+			st.mu.Unlock()
+			writeNotFound(w, "event", eventID)
+
+			return
+		}
+	}
+
+	if webhookID != "" {
+		if _, wh := st.webhook(webhookID); wh == nil {
+			st.mu.Unlock()
+			writeNotFound(w, "webhook", webhookID)
+
+			return
+		}
+	}
+
+	items := make([]api.Attempt, 0)
+	for _, attempt := range st.Attempts {
+		if (eventID == "" || attempt.EventID == eventID) &&
+			(webhookID == "" || attempt.WebhookID == webhookID) &&
+			(status == "" || attempt.Status == status) &&
+			(eventType == "" || matchEventType(eventType, attempt.EventType)) &&
+			attempt.CreatedAt.After(createdAfter) {
+			items = append(items, *attempt)
+		}
+	}
+	st.mu.Unlock()
+
+	start, end, p := paginate(r, len(items))
+	writePage(w, items[start:end], p)
+}
+
+func validateEvent(params api.EventParams) []api.FieldError {
+	var details []api.FieldError
+	if params.Type == "" {
+		details = append(details, api.FieldError{Field: "type", Message: "is required"})
+	}
+
+	if len(params.Data) == 0 {
+		details = append(details, api.FieldError{Field: "data", Message: "is required"})
+	}
+
+	return details
+}
+
+// createEvent creates an event and dispatches it, or schedules it when it is
+// to be delivered later, unless an event was already created with the same
+// idempotency key, which is returned instead. It must be called with the lock
+// held.
+func (a *account) createEvent(params api.EventParams) (*api.Event, bool) {
+	st := a.store
+
+	if key := params.IdempotencyKey; key != "" {
+		if _, ev := st.event(st.IdempotencyKeys[key]); ev != nil {
+			return ev, false
+		}
+	}
+
+	now := time.Now().UTC()
+	ev := &api.Event{
+		ID:        st.nextID("evt"),
+		Type:      params.Type,
+		Data:      params.Data,
+		Status:    api.EventStatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if params.DeliverAt != nil && params.DeliverAt.After(now) {
+		at := params.DeliverAt.UTC()
+		ev.DeliverAt = &at
+		ev.Status = api.EventStatusScheduled
+	}
+
+	st.Events = append(st.Events, ev)
+
+	if key := params.IdempotencyKey; key != "" {
+		if st.IdempotencyKeys == nil {
+			st.IdempotencyKeys = map[string]string{}
+		}
+
+		st.IdempotencyKeys[key] = ev.ID
+	}
+
+	if ev.Status == api.EventStatusScheduled {
+		a.schedule(ev)
+	} else {
+		a.dispatch(ev)
+	}
+
+	return ev, true
+}
@@ -0,0 +1,333 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/delivery"
+)
+
+func (a *account) serveEventTypes(w http.ResponseWriter, r *http.Request, rest []string) {
+	st := a.store
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		st.mu.Lock()
+		items := make([]api.EventType, 0, len(st.EventTypes))
+		for _, et := range st.EventTypes {
+			items = append(items, *et)
+		}
+		st.mu.Unlock()
+
+		start, end, p := paginate(r, len(items))
+		writePage(w, items[start:end], p)
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		var et api.EventType
+		if _, ok := readBody(w, r, &et); !ok {
+			return
+		}
+
+		if et.Name == "" {
+			writeValidationError(w, api.FieldError{Field: "name", Message: "is required"})
+			return
+		}
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		for _, existing := range st.EventTypes {
+			if existing.Name == et.Name {
+				writeError(w, http.StatusConflict, "conflict", "event type "+et.Name+" already exists")
+				return
+			}
+		}
+
+		now := time.Now().UTC()
+		et.ID = st.nextID("et")
+		et.CreatedAt, et.UpdatedAt = now, now
+		st.recordSchema(&et)
+		st.EventTypes = append(st.EventTypes, &et)
+
+		a.commit(w, http.StatusCreated, et)
+	case len(rest) == 1:
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		i, et := st.eventType(rest[0])
+		if et == nil {
+			writeNotFound(w, "event type", rest[0])
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, *et)
+		case http.MethodPatch:
+			updated := *et
+			if _, ok := readBody(w, r, &updated); !ok {
+				return
+			}
+
+			updated.ID, updated.Name, updated.CreatedAt = et.ID, et.Name, et.CreatedAt
+			updated.UpdatedAt = time.Now().UTC()
+			st.recordSchema(&updated)
+			st.EventTypes[i] = &updated
+
+			a.commit(w, http.StatusOK, updated)
+		case http.MethodDelete:
+			st.EventTypes = append(st.EventTypes[:i], st.EventTypes[i+1:]...)
+			delete(st.SchemaVersions, et.ID)
+			a.commit(w, http.StatusNoContent, nil)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	case len(rest) == 2 && rest[1] == "schemas" && r.Method == http.MethodGet:
+		st.mu.Lock()
+		_, et := st.eventType(rest[0])
+		if et == nil {
+			st.mu.Unlock()
+			writeNotFound(w, "event type", rest[0])
+
+			return
+		}
+
+		items := append([]api.SchemaVersion{}, st.SchemaVersions[et.ID]...)
+		st.mu.Unlock()
+
+		start, end, p := paginate(r, len(items))
+		writePage(w, items[start:end], p)
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func (a *account) serveWebhooks(w http.ResponseWriter, r *http.Request, rest []string) {
+	st := a.store
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		st.mu.Lock()
+		items := make([]api.Webhook, 0, len(st.Webhooks))
+		for _, wh := range st.Webhooks {
+			items = append(items, *wh)
+		}
+		st.mu.Unlock()
+
+		start, end, p := paginate(r, len(items))
+		writePage(w, items[start:end], p)
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		var wh api.Webhook
+		if _, ok := readBody(w, r, &wh); !ok {
+			return
+		}
+
+		if details := validateWebhook(&wh); len(details) > 0 {
+			writeValidationError(w, details...)
+			return
+		}
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		now := time.Now().UTC()
+		wh.ID = st.nextID("wh")
+		wh.CreatedAt, wh.UpdatedAt = now, now
+
+		if wh.Secret == "" {
+			wh.Secret = delivery.NewID("whsec")
+		}
+
+		st.Webhooks = append(st.Webhooks, &wh)
+
+		a.commit(w, http.StatusCreated, wh)
+	case len(rest) == 2 && rest[1] == "attempts":
+		a.serveAttempts(w, r, "", rest[0])
+	case len(rest) == 1:
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		i, wh := st.webhook(rest[0])
+		if wh == nil {
+			writeNotFound(w, "webhook", rest[0])
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, *wh)
+		case http.MethodPatch:
+			updated := *wh
+			if _, ok := readBody(w, r, &updated); !ok {
+				return
+			}
+
+			if details := validateWebhook(&updated); len(details) > 0 {
+				writeValidationError(w, details...)
+				return
+			}
+
+			updated.ID, updated.CreatedAt = wh.ID, wh.CreatedAt
+			updated.UpdatedAt = time.Now().UTC()
+			st.Webhooks[i] = &updated
+
+			a.commit(w, http.StatusOK, updated)
+		case http.MethodDelete:
+			st.Webhooks = append(st.Webhooks[:i], st.Webhooks[i+1:]...)
+
+			subs := st.Subscriptions[:0]
+			for _, sub := range st.Subscriptions {
+				if sub.WebhookID != wh.ID {
+					subs = append(subs, sub)
+				}
+			}
+
+			st.Subscriptions = subs
+
+			a.commit(w, http.StatusNoContent, nil)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+func validateWebhook(wh *api.Webhook) []api.FieldError {
+	u, err := url.Parse(wh.URL)
+	if wh.URL == "" {
+		return []api.FieldError{{Field: "url", Message: "is required"}}
+	}
+
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return []api.FieldError{{Field: "url", Message: "must be an absolute http or https URL"}}
+	}
+
+	return nil
+}
+
+func (a *account) serveSubscriptions(w http.ResponseWriter, r *http.Request, rest []string) {
+	st := a.store
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		webhookID := r.URL.Query().Get("webhook_id")
+
+		st.mu.Lock()
+		items := make([]api.Subscription, 0, len(st.Subscriptions))
+		for _, sub := range st.Subscriptions {
+			if webhookID == "" || sub.WebhookID == webhookID {
+				items = append(items, *sub)
+			}
+		}
+		st.mu.Unlock()
+
+		start, end, p := paginate(r, len(items))
+		writePage(w, items[start:end], p)
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		var sub api.Subscription
+		if _, ok := readBody(w, r, &sub); !ok {
+			return
+		}
+
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		if details := a.validateSubscription(&sub); len(details) > 0 {
+			writeValidationError(w, details...)
+			return
+		}
+
+		now := time.Now().UTC()
+		sub.ID = st.nextID("sub")
+		sub.CreatedAt, sub.UpdatedAt = now, now
+		st.Subscriptions = append(st.Subscriptions, &sub)
+
+		a.commit(w, http.StatusCreated, sub)
+	case len(rest) == 1:
+		st.mu.Lock()
+		defer st.mu.Unlock()
+
+		i, sub := st.subscription(rest[0])
+		if sub == nil {
+			writeNotFound(w, "subscription", rest[0])
+			return
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSON(w, http.StatusOK, *sub)
+		case http.MethodPatch:
+			updated := *sub
+			if _, ok := readBody(w, r, &updated); !ok {
+				return
+			}
+
+			if details := a.validateSubscription(&updated); len(details) > 0 {
+				writeValidationError(w, details...)
+				return
+			}
+
+			updated.ID, updated.CreatedAt = sub.ID, sub.CreatedAt
+			updated.UpdatedAt = time.Now().UTC()
+			st.Subscriptions[i] = &updated
+
+			a.commit(w, http.StatusOK, updated)
+		case http.MethodDelete:
+			st.Subscriptions = append(st.Subscriptions[:i], st.Subscriptions[i+1:]...)
+			a.commit(w, http.StatusNoContent, nil)
+		default:
+			writeMethodNotAllowed(w)
+		}
+	default:
+		writeMethodNotAllowed(w)
+	}
+}
+
+// validateSubscription must be called with the store lock held.
+func (a *account) validateSubscription(sub *api.Subscription) []api.FieldError {
+	var details []api.FieldError
+
+	if _, wh := a.store.webhook(sub.WebhookID); wh == nil {
+		details = append(details, api.FieldError{Field: "webhook_id", Message: "does not reference a webhook"})
+	}
+
+	if sub.EventType == "" {
+		details = append(details, api.FieldError{Field: "event_type", Message: "is required"})
+	}
+
+	return details
+}
+
+// commit saves the state and writes the response. It must be called with
+// the store lock held.
+func (a *account) commit(w http.ResponseWriter, status int, data interface{}) {
+	if err := a.store.save(); err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	if status == http.StatusNoContent {
+		w.WriteHeader(status)
+		return
+	}
+
+	writeJSON(w, status, data)
+}
@@ -0,0 +1,349 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package mock implements an in-memory stand-in for the Xigubo API, so the
+// CLI and the services it configures can be exercised without network
+// access.
+package mock
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/delivery"
+)
+
+const (
+	accountsPrefix = "/" + api.Version + "/accounts/"
+	maxBodySize    = 5 << 20
+)
+
+// DefaultRetryDelays are the waits between delivery attempts.
+var DefaultRetryDelays = []time.Duration{time.Second, 5 * time.Second, 30 * time.Second}
+
+type Options struct {
+	// DataFile, when set, is where the state is loaded from and saved to.
+	DataFile string
+	// RetryDelays are the waits between delivery attempts. A delivery is
+	// given up after len(RetryDelays)+1 attempts.
+	RetryDelays []time.Duration
+	// Log receives a line for every request served and delivery attempted.
+	Log io.Writer
+}
+
+type Server struct {
+	file        *dataFile
+	retryDelays []time.Duration
+	client      *http.Client
+	log         io.Writer
+	logMu       sync.Mutex
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	// accounts holds the state of each account, created on first use so
+	// that any account ID can be used. Guarded by mu.
+	mu       sync.Mutex
+	accounts map[string]*account
+}
+
+// account serves the requests of an account from its own state.
+type account struct {
+	*Server
+
+	store *store
+
+	// targets tracks, per event, the outcome of the delivery to each
+	// webhook it was dispatched to. Guarded by store.mu.
+	targets map[string]map[string]string
+
+	// hub relays dispatched deliveries to "xibugo listen".
+	hub *hub
+}
+
+func New(opts Options) (*Server, error) {
+	file, err := openDataFile(opts.DataFile)
+	if err != nil {
+		return nil, err
+	}
+
+	retryDelays := opts.RetryDelays
+	if retryDelays == nil {
+		retryDelays = DefaultRetryDelays
+	}
+
+	logw := opts.Log
+	if logw == nil {
+		logw = io.Discard
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s := &Server{
+		file:        file,
+		retryDelays: retryDelays,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		log:         logw,
+		ctx:         ctx,
+		cancel:      cancel,
+		accounts:    map[string]*account{},
+	}
+
+	// Accounts loaded from the data file may have deliveries in progress.
+	for _, id := range file.list() {
+		if _, err := s.account(id); err != nil {
+			cancel()
+			s.wg.Wait()
+
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// account returns the account with the given ID, loading it from the data
+// file or creating it on first use.
+func (s *Server) account(id string) (*account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if a, ok := s.accounts[id]; ok {
+		return a, nil
+	}
+
+	st, err := s.file.open(id)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &account{
+		Server:  s,
+		store:   st,
+		targets: map[string]map[string]string{},
+		hub:     newHub(),
+	}
+
+	a.resume()
+	s.accounts[id] = a
+
+	return a, nil
+}
+
+// Close stops pending deliveries and waits for those in flight.
+func (s *Server) Close() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Server) logf(format string, args ...interface{}) {
+	s.logMu.Lock()
+	defer s.logMu.Unlock()
+
+	fmt.Fprintf(s.log, time.Now().Format("15:04:05")+" "+format+"\n", args...)
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Flush lets handlers stream their response through the recorder.
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	rec.Header().Set(api.HeaderRequestID, delivery.NewID("req"))
+
+	s.route(rec, r)
+	s.logf("%s %s %d", r.Method, r.URL.RequestURI(), rec.status)
+}
+
+func (s *Server) route(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.URL.Path, accountsPrefix) {
+		writeError(w, http.StatusNotFound, "not_found", "no such endpoint")
+		return
+	}
+
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ") {
+		writeError(w, http.StatusUnauthorized, "unauthorized", "missing access token")
+		return
+	}
+
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, accountsPrefix), "/"), "/")
+	id, rest := segments[0], segments[1:]
+
+	if id == "" {
+		writeError(w, http.StatusNotFound, "not_found", "no such endpoint")
+		return
+	}
+
+	a, err := s.account(id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "internal_error", err.Error())
+		return
+	}
+
+	if len(rest) == 0 {
+		if r.Method != http.MethodGet {
+			writeMethodNotAllowed(w)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, api.Account{
+			ID:    id,
+			Name:  "Mock account",
+			Email: "mock@localhost",
+		})
+
+		return
+	}
+
+	switch rest[0] {
+	case "event-types":
+		a.serveEventTypes(w, r, rest[1:])
+	case "webhooks":
+		a.serveWebhooks(w, r, rest[1:])
+	case "subscriptions":
+		a.serveSubscriptions(w, r, rest[1:])
+	case "events":
+		a.serveEvents(w, r, rest[1:])
+	case "deliveries":
+		if len(rest) != 2 || rest[1] != "stream" {
+			writeError(w, http.StatusNotFound, "not_found", "no such endpoint")
+			return
+		}
+
+		a.serveDeliveryStream(w, r)
+	case "attempts":
+		if len(rest) > 1 {
+			writeError(w, http.StatusNotFound, "not_found", "no such endpoint")
+			return
+		}
+
+		a.serveAttempts(w, r, "", "")
+	default:
+		writeError(w, http.StatusNotFound, "not_found", "no such endpoint")
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	writeBody(w, status, map[string]interface{}{"data": data})
+}
+
+func writeBody(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string, details ...api.FieldError) {
+	writeBody(w, status, map[string]interface{}{
+		"error": api.Error{
+			StatusCode: status,
+			Code:       code,
+			Message:    message,
+			Details:    details,
+		},
+	})
+}
+
+func writeValidationError(w http.ResponseWriter, details ...api.FieldError) {
+	writeError(w, http.StatusUnprocessableEntity, "validation_failed", "validation failed", details...)
+}
+
+func writeNotFound(w http.ResponseWriter, kind, id string) {
+	writeError(w, http.StatusNotFound, "not_found", fmt.Sprintf("%s %q not found", kind, id))
+}
+
+func writeMethodNotAllowed(w http.ResponseWriter) {
+	writeError(w, http.StatusMethodNotAllowed, "method_not_allowed", "method not allowed")
+}
+
+// readBody reads the request body and decodes it into v, answering with
+// 400 and returning false when it is not valid JSON.
+func readBody(w http.ResponseWriter, r *http.Request, v interface{}) ([]byte, bool) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", err.Error())
+		return nil, false
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		writeError(w, http.StatusBadRequest, "bad_request", "invalid JSON: "+err.Error())
+		return nil, false
+	}
+
+	return body, true
+}
+
+// paginate returns the bounds of the requested page of a collection of n
+// items.
+func paginate(r *http.Request, n int) (start, end int, p api.Pagination) {
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage < 1 {
+		perPage = api.DefaultPerPage
+	}
+
+	p = api.Pagination{
+		CurrentPage:  page,
+		PerPage:      perPage,
+		TotalEntries: n,
+		TotalPages:   (n + perPage - 1) / perPage,
+	}
+
+	start = (page - 1) * perPage
+	if start > n {
+		start = n
+	}
+
+	end = start + perPage
+	if end > n {
+		end = n
+	}
+
+	return start, end, p
+}
+
+func writePage(w http.ResponseWriter, data interface{}, p api.Pagination) {
+	writeBody(w, http.StatusOK, map[string]interface{}{
+		"data":       data,
+		"pagination": p,
+	})
+}
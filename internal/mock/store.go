@@ -0,0 +1,268 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+)
+
+// state is everything the mock server knows about an account, as persisted
+// to disk.
+type state struct {
+	Seq           int                 `json:"seq"`
+	EventTypes    []*api.EventType    `json:"event_types"`
+	Webhooks      []*api.Webhook      `json:"webhooks"`
+	Subscriptions []*api.Subscription `json:"subscriptions"`
+	Events        []*api.Event        `json:"events"`
+	Attempts      []*api.Attempt      `json:"attempts"`
+
+	// SchemaVersions holds the schema history of each event type, by ID.
+	SchemaVersions map[string][]api.SchemaVersion `json:"schema_versions,omitempty"`
+
+	// IdempotencyKeys maps the idempotency keys events were created with to
+	// their IDs.
+	IdempotencyKeys map[string]string `json:"idempotency_keys,omitempty"`
+}
+
+// store is the state of an account.
+type store struct {
+	mu      sync.Mutex
+	account string
+	file    *dataFile
+	state
+}
+
+// save writes the state to the data file, if any. It must be called with the
+// lock held.
+func (s *store) save() error {
+	if s.file == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(s.state)
+	if err != nil {
+		return err
+	}
+
+	return s.file.save(s.account, data)
+}
+
+// dataFile persists the state of every account to a single file, keeping the
+// last saved state of each so that an account can be saved without locking
+// the others.
+type dataFile struct {
+	mu       sync.Mutex
+	path     string
+	accounts map[string]json.RawMessage
+}
+
+// openDataFile loads the states saved at path, if any. An empty path keeps
+// the states in memory only and returns nil.
+func openDataFile(path string) (*dataFile, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	f := &dataFile{path: path, accounts: map[string]json.RawMessage{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var saved struct {
+		Accounts map[string]json.RawMessage `json:"accounts"`
+	}
+
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("loading %s: %w", path, err)
+	}
+
+	for id, raw := range saved.Accounts {
+		f.accounts[id] = raw
+	}
+
+	return f, nil
+}
+
+// open returns the store of an account, loaded from the file when it was
+// saved before. A nil dataFile returns an empty store kept in memory.
+func (f *dataFile) open(account string) (*store, error) {
+	s := &store{account: account}
+	if f == nil {
+		return s, nil
+	}
+
+	s.file = f
+
+	f.mu.Lock()
+	raw, ok := f.accounts[account]
+	f.mu.Unlock()
+
+	if !ok {
+		return s, nil
+	}
+
+	if err := json.Unmarshal(raw, &s.state); err != nil {
+		return nil, fmt.Errorf("loading account %s from %s: %w", account, f.path, err)
+	}
+
+	return s, nil
+}
+
+// list returns the IDs of the accounts saved in the file.
+func (f *dataFile) list() []string {
+	if f == nil {
+		return nil
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ids := make([]string, 0, len(f.accounts))
+	for id := range f.accounts {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+// save replaces the saved state of an account and writes the file.
+func (f *dataFile) save(account string, state json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.accounts[account] = state
+
+	data, err := json.MarshalIndent(struct {
+		Accounts map[string]json.RawMessage `json:"accounts"`
+	}{f.accounts}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), filepath.Base(f.path)+".*")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), f.path)
+}
+
+// nextID returns a new identifier. It must be called with the lock held.
+func (s *store) nextID(prefix string) string {
+	s.Seq++
+	return fmt.Sprintf("%s_%d", prefix, s.Seq)
+}
+
+func (s *store) eventType(id string) (int, *api.EventType) {
+	for i, et := range s.EventTypes {
+		if et.ID == id || et.Name == id {
+			return i, et
+		}
+	}
+
+	return -1, nil
+}
+
+// recordSchema adds the schema of et to its history when it differs from
+// the latest version, and sets its version. It must be called with the lock
+// held.
+func (s *store) recordSchema(et *api.EventType) {
+	if len(et.Schema) == 0 {
+		return
+	}
+
+	var schema bytes.Buffer
+	if err := json.Compact(&schema, et.Schema); err != nil {
+		return
+	}
+
+	versions := s.SchemaVersions[et.ID]
+	if n := len(versions); n > 0 && bytes.Equal(versions[n-1].Schema, schema.Bytes()) {
+		et.Version = versions[n-1].Version
+		return
+	}
+
+	if s.SchemaVersions == nil {
+		s.SchemaVersions = map[string][]api.SchemaVersion{}
+	}
+
+	et.Version = len(versions) + 1
+	s.SchemaVersions[et.ID] = append(versions, api.SchemaVersion{
+		Version:   et.Version,
+		Schema:    schema.Bytes(),
+		CreatedAt: et.UpdatedAt,
+	})
+}
+
+func (s *store) webhook(id string) (int, *api.Webhook) {
+	for i, wh := range s.Webhooks {
+		if wh.ID == id {
+			return i, wh
+		}
+	}
+
+	return -1, nil
+}
+
+func (s *store) subscription(id string) (int, *api.Subscription) {
+	for i, sub := range s.Subscriptions {
+		if sub.ID == id {
+			return i, sub
+		}
+	}
+
+	return -1, nil
+}
+
+func (s *store) event(id string) (int, *api.Event) {
+	for i, ev := range s.Events {
+		if ev.ID == id {
+			return i, ev
+		}
+	}
+
+	return -1, nil
+}
@@ -0,0 +1,227 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getumbeluzi/xibugo-cli/internal/api"
+	"github.com/getumbeluzi/xibugo-cli/internal/delivery"
+)
+
+const (
+	// streamBacklog is how many recent deliveries are kept for listeners
+	// resuming with Last-Event-ID.
+	streamBacklog = 1000
+	// streamBuffer is how many deliveries a slow listener may fall behind
+	// before it is disconnected, to resume later.
+	streamBuffer      = 64
+	streamKeepAlive   = 15 * time.Second
+	sseEventDelivery  = "delivery"
+	mediaTypeSSE      = "text/event-stream"
+	headerLastEventID = "Last-Event-ID"
+)
+
+// hub relays dispatched deliveries to the clients listening on the
+// delivery stream.
+type hub struct {
+	mu        sync.Mutex
+	recent    []api.Delivery
+	listeners map[chan api.Delivery]struct{}
+}
+
+func newHub() *hub {
+	return &hub{listeners: map[chan api.Delivery]struct{}{}}
+}
+
+// publish sends a delivery to every listener, disconnecting those that
+// cannot keep up.
+func (h *hub) publish(d api.Delivery) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.recent = append(h.recent, d)
+	if len(h.recent) > streamBacklog {
+		h.recent = h.recent[len(h.recent)-streamBacklog:]
+	}
+
+	for ch := range h.listeners {
+		select {
+		case ch <- d:
+		default:
+			delete(h.listeners, ch)
+			close(ch)
+		}
+	}
+}
+
+// subscribe registers a listener, returning the deliveries published after
+// lastID, which it missed, and a channel receiving the next ones. The
+// channel is closed when the listener falls too far behind.
+func (h *hub) subscribe(lastID string) ([]api.Delivery, chan api.Delivery) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var missed []api.Delivery
+
+	if lastID != "" {
+		for i, d := range h.recent {
+			if d.ID == lastID {
+				missed = append(missed, h.recent[i+1:]...)
+				break
+			}
+		}
+	}
+
+	ch := make(chan api.Delivery, streamBuffer)
+	h.listeners[ch] = struct{}{}
+
+	return missed, ch
+}
+
+func (h *hub) unsubscribe(ch chan api.Delivery) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.listeners[ch]; ok {
+		delete(h.listeners, ch)
+		close(ch)
+	}
+}
+
+// publishDelivery relays a dispatched event to the delivery stream.
+func (a *account) publishDelivery(ev *api.Event) {
+	payload, err := json.Marshal(delivery.Payload{
+		ID:        ev.ID,
+		Type:      ev.Type,
+		CreatedAt: ev.CreatedAt,
+		Data:      ev.Data,
+	})
+	if err != nil {
+		return
+	}
+
+	id := delivery.NewID("dlv")
+
+	a.hub.publish(api.Delivery{
+		ID:        id,
+		EventID:   ev.ID,
+		EventType: ev.Type,
+		Headers: map[string]string{
+			"Content-Type":            "application/json",
+			delivery.HeaderDeliveryID: id,
+			delivery.HeaderEventID:    ev.ID,
+			delivery.HeaderEventType:  ev.Type,
+		},
+		Payload:   payload,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// serveDeliveryStream streams deliveries as server-sent events, replaying
+// those published after the Last-Event-ID header first.
+func (a *account) serveDeliveryStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeMethodNotAllowed(w)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, "internal_error", "streaming is not supported")
+		return
+	}
+
+	var types []string
+	if value := r.URL.Query().Get("types"); value != "" {
+		types = strings.Split(value, ",")
+	}
+
+	missed, ch := a.hub.subscribe(r.Header.Get(headerLastEventID))
+	defer a.hub.unsubscribe(ch)
+
+	w.Header().Set("Content-Type", mediaTypeSSE)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	send := func(d api.Delivery) error {
+		if !matchAnyEventType(types, d.EventType) {
+			return nil
+		}
+
+		data, err := json.Marshal(d)
+		if err != nil {
+			return err
+		}
+
+		if _, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", d.ID, sseEventDelivery, data); err != nil {
+			return err
+		}
+
+		flusher.Flush()
+
+		return nil
+	}
+
+	for _, d := range missed {
+		if send(d) != nil {
+			return
+		}
+	}
+
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-a.ctx.Done():
+			return
+		case <-keepAlive.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+
+			flusher.Flush()
+		case d, ok := <-ch:
+			if !ok || send(d) != nil {
+				return
+			}
+		}
+	}
+}
+
+func matchAnyEventType(patterns []string, eventType string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+
+	for _, p := range patterns {
+		if matchEventType(strings.TrimSpace(p), eventType) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,110 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package progress draws a progress bar on a terminal.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+const width = 30
+
+// Bar reports the progress of a fixed number of operations, or only counts
+// them when the total is not known. It is safe for concurrent use. Nothing
+// is drawn when it is disabled, so callers need not check whether they are
+// writing to a terminal.
+type Bar struct {
+	mu      sync.Mutex
+	out     io.Writer
+	enabled bool
+	total   int
+	done    int
+	failed  int
+}
+
+// New returns a bar for total operations. A total of zero means it is not
+// known in advance.
+func New(out io.Writer, total int, enabled bool) *Bar {
+	return &Bar{out: out, total: total, enabled: enabled}
+}
+
+// Add records the outcome of one operation and redraws the bar.
+func (b *Bar) Add(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.done++
+	if !ok {
+		b.failed++
+	}
+
+	b.draw()
+}
+
+// Printf writes a message above the bar.
+func (b *Bar) Printf(format string, args ...interface{}) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.enabled {
+		fmt.Fprint(b.out, "\r\x1b[K")
+	}
+
+	fmt.Fprintf(b.out, format, args...)
+	b.draw()
+}
+
+// Finish ends the line the bar is drawn on.
+func (b *Bar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.enabled {
+		fmt.Fprintln(b.out)
+	}
+}
+
+func (b *Bar) draw() {
+	if !b.enabled || b.done == 0 && b.total == 0 {
+		return
+	}
+
+	if b.total == 0 {
+		fmt.Fprintf(b.out, "\r%d done", b.done)
+	} else {
+		filled := width * b.done / b.total
+		if filled > width {
+			filled = width
+		}
+
+		fmt.Fprintf(
+			b.out,
+			"\r[%s%s] %d/%d",
+			strings.Repeat("#", filled),
+			strings.Repeat("-", width-filled),
+			b.done,
+			b.total,
+		)
+	}
+
+	if b.failed > 0 {
+		fmt.Fprintf(b.out, " (%d failed)", b.failed)
+	}
+}
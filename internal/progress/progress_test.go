@@ -0,0 +1,111 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package progress
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBar(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int
+		enabled bool
+		results []bool
+		want    string
+	}{
+		{
+			name:    "disabled",
+			total:   2,
+			results: []bool{true, false},
+		},
+		{
+			name:    "known total",
+			total:   4,
+			enabled: true,
+			results: []bool{true, false},
+			want:    "[###############---------------] 2/4 (1 failed)",
+		},
+		{
+			name:    "unknown total",
+			enabled: true,
+			results: []bool{true, true, true},
+			want:    "3 done",
+		},
+		{
+			name:    "more than the total",
+			total:   1,
+			enabled: true,
+			results: []bool{true, true},
+			want:    "[##############################] 2/1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+
+			b := New(&out, tt.total, tt.enabled)
+			for _, ok := range tt.results {
+				b.Add(ok)
+			}
+
+			b.Finish()
+
+			got := out.String()
+			if !tt.enabled {
+				if got != "" {
+					t.Errorf("disabled bar wrote %q", got)
+				}
+
+				return
+			}
+
+			frames := strings.Split(strings.TrimSuffix(got, "\n"), "\r")
+			if last := frames[len(frames)-1]; last != tt.want {
+				t.Errorf("last frame is %q, want %q", last, tt.want)
+			}
+		})
+	}
+}
+
+func TestBarPrintf(t *testing.T) {
+	var out bytes.Buffer
+
+	b := New(&out, 2, true)
+	b.Add(true)
+	b.Printf("line %d: %s\n", 2, "failed")
+
+	if want := "\r\x1b[K" + "line 2: failed\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("got %q, want the bar cleared before the message", out.String())
+	}
+
+	if !strings.HasSuffix(out.String(), "1/2") {
+		t.Errorf("got %q, want the bar redrawn after the message", out.String())
+	}
+
+	out.Reset()
+
+	b = New(&out, 2, false)
+	b.Printf("line %d\n", 1)
+
+	if out.String() != "line 1\n" {
+		t.Errorf("disabled bar printed %q, want the message alone", out.String())
+	}
+}
@@ -0,0 +1,97 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiterSpacesOperations(t *testing.T) {
+	l := New(50)
+
+	start := time.Now()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			if err := l.Wait(context.Background()); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// The first operation goes at once, the other four 20ms apart.
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Errorf("5 operations at 50/s took %v, want at least 80ms", elapsed)
+	}
+}
+
+func TestLimiterWithoutRate(t *testing.T) {
+	for _, rate := range []float64{0, -1} {
+		l := New(rate)
+
+		start := time.Now()
+
+		for i := 0; i < 1000; i++ {
+			if err := l.Wait(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("rate %v: 1000 operations took %v, want no limit", rate, elapsed)
+		}
+	}
+}
+
+func TestLimiterCancel(t *testing.T) {
+	l := New(0.1)
+
+	if err := l.Wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+
+	if err := l.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the context error", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("waited %v after the context ended", elapsed)
+	}
+
+	cancel()
+
+	if err := New(0).Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("unlimited wait on an ended context got %v, want the context error", err)
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultCacheTTL is how long a fetched schema is reused before it is
+// fetched again.
+const DefaultCacheTTL = 10 * time.Minute
+
+// Cache keeps schemas on disk, one file per key, so validating many events
+// does not fetch the same schema each time. The absence of a schema is
+// cached too.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	Now func() time.Time
+}
+
+type cacheEntry struct {
+	FetchedAt time.Time       `json:"fetched_at"`
+	Schema    json.RawMessage `json:"schema"`
+}
+
+// NewCache returns a cache in dir with the default TTL.
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir, TTL: DefaultCacheTTL}
+}
+
+// Get returns the cached schema for key, which is nil when the cache records
+// that there is none. ok is false when nothing fresh is cached.
+func (c *Cache) Get(key string) (schema json.RawMessage, ok bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return nil, false
+	}
+
+	var entry cacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, false
+	}
+
+	if c.now().Sub(entry.FetchedAt) > c.TTL {
+		return nil, false
+	}
+
+	if string(entry.Schema) == "null" {
+		return nil, true
+	}
+
+	return entry.Schema, true
+}
+
+// Put stores the schema for key. A nil schema records that there is none.
+func (c *Cache) Put(key string, schema json.RawMessage) error {
+	if len(schema) == 0 {
+		schema = json.RawMessage("null")
+	}
+
+	data, err := json.Marshal(cacheEntry{FetchedAt: c.now(), Schema: schema})
+	if err != nil {
+		return err
+	}
+
+	path := c.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so concurrent readers never see a
+	// partial entry.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".schema-*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Delete forgets the schema cached for key, so that it is fetched again.
+func (c *Cache) Delete(key string) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.Dir, filepath.FromSlash(key)+".json")
+}
+
+func (c *Cache) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+
+	return time.Now()
+}
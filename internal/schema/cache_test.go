@@ -0,0 +1,81 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCache(t *testing.T) {
+	now := time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	c := NewCache(t.TempDir())
+	c.Now = func() time.Time { return now }
+
+	if _, ok := c.Get("acc_1/order.created"); ok {
+		t.Fatal("got a schema from an empty cache")
+	}
+
+	if err := c.Put("acc_1/order.created", []byte(`{"type":"object"}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.Put("acc_1/order.deleted", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		key    string
+		after  time.Duration
+		ok     bool
+		schema string
+	}{
+		{name: "fresh", key: "acc_1/order.created", after: time.Minute, ok: true, schema: `{"type":"object"}`},
+		{name: "at the TTL", key: "acc_1/order.created", after: DefaultCacheTTL, ok: true, schema: `{"type":"object"}`},
+		{name: "expired", key: "acc_1/order.created", after: DefaultCacheTTL + time.Second},
+		{name: "no schema", key: "acc_1/order.deleted", after: time.Minute, ok: true},
+		{name: "no schema expired", key: "acc_1/order.deleted", after: DefaultCacheTTL + time.Second},
+		{name: "other account", key: "acc_2/order.created", after: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c.Now = func() time.Time { return now.Add(tt.after) }
+
+			schema, ok := c.Get(tt.key)
+			if ok != tt.ok || string(schema) != tt.schema {
+				t.Errorf("got %s, %t, want %s, %t", schema, ok, tt.schema, tt.ok)
+			}
+		})
+	}
+
+	c.Now = func() time.Time { return now }
+
+	if err := c.Delete("acc_1/order.created"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := c.Get("acc_1/order.created"); ok {
+		t.Error("got a deleted schema")
+	}
+
+	if err := c.Delete("acc_1/order.created"); err != nil {
+		t.Errorf("deleting twice: %v", err)
+	}
+}
@@ -0,0 +1,391 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Change is a difference between two versions of a schema, at the location in
+// the payload given by a JSON pointer. Array elements are written as "*", as
+// in "/lines/*/sku".
+//
+// A breaking change is one that can break consumers or producers of the
+// event: a field removed or no longer required, a type changed, a new
+// required field or a tighter constraint. Everything else, such as a new
+// optional field or a looser constraint, is backward-compatible.
+type Change struct {
+	Pointer  string `json:"pointer"`
+	Message  string `json:"message"`
+	Breaking bool   `json:"breaking"`
+}
+
+func (c Change) String() string {
+	return Error{Pointer: c.Pointer, Message: c.Message}.String()
+}
+
+// Breaking returns the breaking changes among changes.
+func Breaking(changes []Change) []Change {
+	var breaking []Change
+
+	for _, c := range changes {
+		if c.Breaking {
+			breaking = append(breaking, c)
+		}
+	}
+
+	return breaking
+}
+
+// lowerBounds and upperBounds are the keywords that tighten a schema when
+// raised and lowered respectively.
+var (
+	lowerBounds = []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+	upperBounds = []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+)
+
+// Compare classifies the changes from the old version of a schema to the new
+// one. A nil old schema means the event type had no schema, so adding one is
+// reported as a single compatible change.
+func Compare(old, new *Schema) []Change {
+	if old == nil {
+		return []Change{{Message: "schema added"}}
+	}
+
+	c := &comparison{old: old, new: new, seen: map[string]bool{}}
+	c.compare(old.root, new.root, "", 0)
+
+	return c.changes
+}
+
+type comparison struct {
+	old, new *Schema
+	seen     map[string]bool
+	changes  []Change
+}
+
+func (c *comparison) add(ptr string, breaking bool, format string, args ...interface{}) {
+	c.changes = append(c.changes, Change{Pointer: ptr, Message: fmt.Sprintf(format, args...), Breaking: breaking})
+}
+
+func (c *comparison) compare(oldNode, newNode interface{}, ptr string, depth int) {
+	if depth > maxDepth {
+		return
+	}
+
+	oldNode, oldRef := c.deref(c.old, oldNode)
+	newNode, newRef := c.deref(c.new, newNode)
+
+	if oldRef != "" || newRef != "" {
+		key := oldRef + "\x00" + newRef
+		if c.seen[key] {
+			return
+		}
+
+		c.seen[key] = true
+	}
+
+	o, oldOK := oldNode.(map[string]interface{})
+	n, newOK := newNode.(map[string]interface{})
+
+	if !oldOK || !newOK {
+		if !equal(oldNode, newNode) {
+			c.add(ptr, true, "schema changed from %s to %s", formatValue(oldNode), formatValue(newNode))
+		}
+
+		return
+	}
+
+	if oldType, newType := typeNames(o), typeNames(n); oldType != newType {
+		c.add(ptr, true, "type changed from %s to %s", typeOrAny(oldType), typeOrAny(newType))
+		return
+	}
+
+	c.compareValues(o, n, ptr)
+	c.compareBounds(o, n, ptr)
+	c.compareObject(o, n, ptr, depth)
+
+	if oldItems, ok := o["items"]; ok {
+		if newItems, ok := n["items"]; ok {
+			c.compare(oldItems, newItems, Pointer(ptr, "*"), depth+1)
+		} else {
+			c.add(Pointer(ptr, "*"), false, "item schema removed")
+		}
+	} else if _, ok := n["items"]; ok {
+		c.add(Pointer(ptr, "*"), true, "item schema added")
+	}
+
+	for _, keyword := range []string{"allOf", "anyOf", "oneOf", "not", "if", "then", "else"} {
+		if !equal(o[keyword], n[keyword]) {
+			c.add(ptr, true, "%s changed", keyword)
+		}
+	}
+}
+
+// deref follows $ref, returning the referenced node and the reference.
+func (c *comparison) deref(s *Schema, node interface{}) (interface{}, string) {
+	n, ok := node.(map[string]interface{})
+	if !ok {
+		return node, ""
+	}
+
+	ref, ok := n["$ref"].(string)
+	if !ok {
+		return node, ""
+	}
+
+	target, err := s.resolve(ref)
+	if err != nil {
+		return node, ""
+	}
+
+	return target, ref
+}
+
+func (c *comparison) compareValues(o, n map[string]interface{}, ptr string) {
+	oldFormat, _ := o["format"].(string)
+	newFormat, _ := n["format"].(string)
+
+	switch {
+	case oldFormat == newFormat:
+	case newFormat == "":
+		c.add(ptr, false, "format %s removed", oldFormat)
+	case oldFormat == "":
+		c.add(ptr, true, "format %s added", newFormat)
+	default:
+		c.add(ptr, true, "format changed from %s to %s", oldFormat, newFormat)
+	}
+
+	if oldConst, ok := o["const"]; ok {
+		if newConst, ok := n["const"]; !ok {
+			c.add(ptr, false, "const %s removed", formatValue(oldConst))
+		} else if !equal(oldConst, newConst) {
+			c.add(ptr, true, "const changed from %s to %s", formatValue(oldConst), formatValue(newConst))
+		}
+	} else if newConst, ok := n["const"]; ok {
+		c.add(ptr, true, "const %s added", formatValue(newConst))
+	}
+
+	oldEnum, oldOK := o["enum"].([]interface{})
+	newEnum, newOK := n["enum"].([]interface{})
+
+	switch {
+	case !oldOK && !newOK:
+	case !newOK:
+		c.add(ptr, false, "enum removed")
+	case !oldOK:
+		c.add(ptr, true, "enum added: %s", formatValues(newEnum))
+	default:
+		if removed := missing(oldEnum, newEnum); len(removed) > 0 {
+			c.add(ptr, true, "enum values removed: %s", formatValues(removed))
+		}
+
+		if added := missing(newEnum, oldEnum); len(added) > 0 {
+			c.add(ptr, false, "enum values added: %s", formatValues(added))
+		}
+	}
+
+	oldPattern, _ := o["pattern"].(string)
+	newPattern, _ := n["pattern"].(string)
+
+	switch {
+	case oldPattern == newPattern:
+	case newPattern == "":
+		c.add(ptr, false, "pattern removed")
+	default:
+		c.add(ptr, true, "pattern changed from %q to %q", oldPattern, newPattern)
+	}
+
+	if !equal(o["multipleOf"], n["multipleOf"]) {
+		if _, ok := n["multipleOf"]; ok {
+			c.add(ptr, true, "multipleOf changed to %s", formatValue(n["multipleOf"]))
+		} else {
+			c.add(ptr, false, "multipleOf removed")
+		}
+	}
+}
+
+func (c *comparison) compareBounds(o, n map[string]interface{}, ptr string) {
+	for i, keywords := range [][]string{lowerBounds, upperBounds} {
+		upper := i == 1
+
+		for _, keyword := range keywords {
+			oldBound, oldOK := ratKeyword(o, keyword)
+			newBound, newOK := ratKeyword(n, keyword)
+
+			switch {
+			case !oldOK && !newOK:
+			case !newOK:
+				c.add(ptr, false, "%s removed", keyword)
+			case !oldOK:
+				c.add(ptr, true, "%s %s added", keyword, newBound.RatString())
+			default:
+				cmp := newBound.Cmp(oldBound)
+				if cmp == 0 {
+					continue
+				}
+
+				tightened := cmp > 0
+				if upper {
+					tightened = cmp < 0
+				}
+
+				c.add(ptr, tightened, "%s changed from %s to %s", keyword, oldBound.RatString(), newBound.RatString())
+			}
+		}
+	}
+}
+
+func (c *comparison) compareObject(o, n map[string]interface{}, ptr string, depth int) {
+	oldProps, _ := o["properties"].(map[string]interface{})
+	newProps, _ := n["properties"].(map[string]interface{})
+	oldRequired := stringSet(o["required"])
+	newRequired := stringSet(n["required"])
+
+	names := map[string]bool{}
+	for name := range oldProps {
+		names[name] = true
+	}
+
+	for name := range newProps {
+		names[name] = true
+	}
+
+	for name := range oldRequired {
+		names[name] = true
+	}
+
+	for name := range newRequired {
+		names[name] = true
+	}
+
+	for _, name := range sortedKeys(names) {
+		p := Pointer(ptr, name)
+		oldProp, inOld := oldProps[name]
+		newProp, inNew := newProps[name]
+
+		switch {
+		case inOld && !inNew:
+			c.add(p, true, "field removed")
+			continue
+		case !inOld && inNew && newRequired[name]:
+			c.add(p, true, "new required field")
+			continue
+		case !inOld && inNew:
+			c.add(p, false, "optional field added")
+			continue
+		}
+
+		switch {
+		case !oldRequired[name] && newRequired[name]:
+			c.add(p, true, "field is now required")
+		case oldRequired[name] && !newRequired[name]:
+			c.add(p, true, "field is no longer required")
+		}
+
+		if inOld && inNew {
+			c.compare(oldProp, newProp, p, depth+1)
+		}
+	}
+
+	oldClosed := o["additionalProperties"] == false
+	newClosed := n["additionalProperties"] == false
+
+	switch {
+	case !oldClosed && newClosed:
+		c.add(ptr, true, "additional properties are no longer allowed")
+	case oldClosed && !newClosed:
+		c.add(ptr, false, "additional properties are now allowed")
+	}
+}
+
+// typeNames returns the sorted types a schema allows, joined by "|", or ""
+// when it does not restrict the type.
+func typeNames(n map[string]interface{}) string {
+	var names []string
+
+	switch t := n["type"].(type) {
+	case string:
+		names = []string{t}
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				names = append(names, name)
+			}
+		}
+	}
+
+	sort.Strings(names)
+
+	return strings.Join(names, "|")
+}
+
+func typeOrAny(t string) string {
+	if t == "" {
+		return "any"
+	}
+
+	return t
+}
+
+// missing returns the values of a that are not in b.
+func missing(a, b []interface{}) []interface{} {
+	var values []interface{}
+
+	for _, v := range a {
+		found := false
+
+		for _, w := range b {
+			if equal(v, w) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			values = append(values, v)
+		}
+	}
+
+	return values
+}
+
+func stringSet(v interface{}) map[string]bool {
+	set := map[string]bool{}
+
+	values, _ := v.([]interface{})
+	for _, value := range values {
+		if s, ok := value.(string); ok {
+			set[s] = true
+		}
+	}
+
+	return set
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
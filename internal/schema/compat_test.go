@@ -0,0 +1,196 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	const base = `{
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "string"},
+			"note": {"type": "string", "maxLength": 100},
+			"status": {"enum": ["pending", "paid"]},
+			"quantity": {"type": "integer", "minimum": 1},
+			"lines": {"type": "array", "items": {"$ref": "#/$defs/line"}}
+		},
+		"$defs": {"line": {"type": "object", "properties": {"sku": {"type": "string"}}}}
+	}`
+
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		changes []Change
+	}{
+		{
+			name: "unchanged",
+			old:  base,
+			new:  base,
+		},
+		{
+			name:    "schema added",
+			new:     base,
+			changes: []Change{{Message: "schema added"}},
+		},
+		{
+			name:    "optional field added",
+			old:     `{"properties": {"id": {"type": "string"}}}`,
+			new:     `{"properties": {"id": {"type": "string"}, "note": {"type": "string"}}}`,
+			changes: []Change{{Pointer: "/note", Message: "optional field added"}},
+		},
+		{
+			name:    "required field added",
+			old:     `{"properties": {"id": {"type": "string"}}}`,
+			new:     `{"required": ["note"], "properties": {"id": {"type": "string"}, "note": {"type": "string"}}}`,
+			changes: []Change{{Pointer: "/note", Message: "new required field", Breaking: true}},
+		},
+		{
+			name:    "field removed",
+			old:     `{"properties": {"id": {"type": "string"}, "note": {"type": "string"}}}`,
+			new:     `{"properties": {"id": {"type": "string"}}}`,
+			changes: []Change{{Pointer: "/note", Message: "field removed", Breaking: true}},
+		},
+		{
+			name:    "field made required",
+			old:     `{"properties": {"id": {"type": "string"}}}`,
+			new:     `{"required": ["id"], "properties": {"id": {"type": "string"}}}`,
+			changes: []Change{{Pointer: "/id", Message: "field is now required", Breaking: true}},
+		},
+		{
+			name:    "field no longer required",
+			old:     `{"required": ["id"], "properties": {"id": {"type": "string"}}}`,
+			new:     `{"properties": {"id": {"type": "string"}}}`,
+			changes: []Change{{Pointer: "/id", Message: "field is no longer required", Breaking: true}},
+		},
+		{
+			name:    "type changed",
+			old:     `{"properties": {"id": {"type": "string"}}}`,
+			new:     `{"properties": {"id": {"type": "integer"}}}`,
+			changes: []Change{{Pointer: "/id", Message: "type changed from string to integer", Breaking: true}},
+		},
+		{
+			name:    "type widened to a union",
+			old:     `{"type": "string"}`,
+			new:     `{"type": ["string", "null"]}`,
+			changes: []Change{{Message: "type changed from string to null|string", Breaking: true}},
+		},
+		{
+			name: "enum values added and removed",
+			old:  `{"enum": ["pending", "paid"]}`,
+			new:  `{"enum": ["paid", "refunded"]}`,
+			changes: []Change{
+				{Message: `enum values removed: "pending"`, Breaking: true},
+				{Message: `enum values added: "refunded"`},
+			},
+		},
+		{
+			name: "bounds loosened",
+			old:  `{"type": "string", "minLength": 2, "maxLength": 10}`,
+			new:  `{"type": "string", "minLength": 1}`,
+			changes: []Change{
+				{Message: "minLength changed from 2 to 1"},
+				{Message: "maxLength removed"},
+			},
+		},
+		{
+			name: "bounds tightened",
+			old:  `{"type": "integer", "maximum": 10}`,
+			new:  `{"type": "integer", "minimum": 1, "maximum": 5}`,
+			changes: []Change{
+				{Message: "minimum 1 added", Breaking: true},
+				{Message: "maximum changed from 10 to 5", Breaking: true},
+			},
+		},
+		{
+			name:    "format added",
+			old:     `{"type": "string"}`,
+			new:     `{"type": "string", "format": "email"}`,
+			changes: []Change{{Message: "format email added", Breaking: true}},
+		},
+		{
+			name:    "pattern removed",
+			old:     `{"type": "string", "pattern": "^a"}`,
+			new:     `{"type": "string"}`,
+			changes: []Change{{Message: "pattern removed"}},
+		},
+		{
+			name:    "additional properties closed",
+			old:     `{"type": "object"}`,
+			new:     `{"type": "object", "additionalProperties": false}`,
+			changes: []Change{{Message: "additional properties are no longer allowed", Breaking: true}},
+		},
+		{
+			name:    "change within array items behind a reference",
+			old:     base,
+			new:     `{"type": "object", "required": ["id"], "properties": {"id": {"type": "string"}, "note": {"type": "string", "maxLength": 100}, "status": {"enum": ["pending", "paid"]}, "quantity": {"type": "integer", "minimum": 1}, "lines": {"type": "array", "items": {"type": "object", "properties": {"sku": {"type": "integer"}}}}}}`,
+			changes: []Change{{Pointer: "/lines/*/sku", Message: "type changed from string to integer", Breaking: true}},
+		},
+		{
+			name: "recursive schemas terminate",
+			old:  `{"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#"}}}}`,
+			new:  `{"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#"}}, "name": {"type": "string"}}}`,
+			changes: []Change{
+				{Pointer: "/children/*/name", Message: "optional field added"},
+				{Pointer: "/name", Message: "optional field added"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var old *Schema
+
+			if tt.old != "" {
+				var err error
+				if old, err = Compile([]byte(tt.old)); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			newSchema, err := Compile([]byte(tt.new))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := Compare(old, newSchema)
+			if len(got) != len(tt.changes) {
+				t.Fatalf("got changes %v, want %v", got, tt.changes)
+			}
+
+			for i := range got {
+				if got[i] != tt.changes[i] {
+					t.Errorf("change %d is %+v, want %+v", i, got[i], tt.changes[i])
+				}
+			}
+
+			breaking := 0
+			for _, c := range tt.changes {
+				if c.Breaking {
+					breaking++
+				}
+			}
+
+			if n := len(Breaking(got)); n != breaking {
+				t.Errorf("got %d breaking changes, want %d", n, breaking)
+			}
+		})
+	}
+}
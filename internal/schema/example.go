@@ -0,0 +1,622 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exampleTime is the instant used for date and date-time examples, so that
+// examples are stable.
+var exampleTime = time.Date(2024, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// Example synthesizes a document matching the schema. Values given by
+// examples, default, const or enum are used as they are; anything else is
+// made up from the type, format, pattern and bounds. Optional properties are
+// included unless they would recurse, and the items of arrays with
+// uniqueItems are made distinct.
+func (s *Schema) Example() (json.RawMessage, error) {
+	g := exampleGenerator{s: s, refs: map[string]bool{}}
+
+	v := g.example(s.root, 0)
+
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+type exampleGenerator struct {
+	s *Schema
+
+	// refs holds the references being expanded, to stop at recursion.
+	refs map[string]bool
+
+	// variant selects which of several possible values is generated, so
+	// that the items of an array with uniqueItems differ.
+	variant int
+}
+
+func (g *exampleGenerator) example(node interface{}, depth int) interface{} {
+	n, ok := node.(map[string]interface{})
+	if !ok || depth > maxDepth {
+		return nil
+	}
+
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := g.s.resolve(ref)
+		if err != nil || g.refs[ref] {
+			return nil
+		}
+
+		g.refs[ref] = true
+		defer delete(g.refs, ref)
+
+		return g.example(target, depth+1)
+	}
+
+	if examples, ok := n["examples"].([]interface{}); ok && len(examples) > 0 {
+		return examples[g.variant%len(examples)]
+	}
+
+	for _, keyword := range []string{"example", "default", "const"} {
+		if v, ok := n[keyword]; ok {
+			return v
+		}
+	}
+
+	if enum, ok := n["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[g.variant%len(enum)]
+	}
+
+	if all, ok := n["allOf"].([]interface{}); ok && len(all) > 0 {
+		return g.merge(n, all, depth)
+	}
+
+	for _, keyword := range []string{"oneOf", "anyOf"} {
+		if alternatives, ok := n[keyword].([]interface{}); ok && len(alternatives) > 0 {
+			return g.example(alternatives[0], depth+1)
+		}
+	}
+
+	switch exampleType(n) {
+	case "string":
+		return g.stringExample(n)
+	case "integer":
+		return numberExample(n, true, g.variant)
+	case "number":
+		return numberExample(n, false, g.variant)
+	case "boolean":
+		return g.variant%2 == 0
+	case "array":
+		return g.arrayExample(n, depth)
+	case "object":
+		return g.objectExample(n, depth)
+	}
+
+	return nil
+}
+
+// merge combines the examples of allOf subschemas, and of the properties
+// declared next to allOf, into one object.
+func (g *exampleGenerator) merge(n map[string]interface{}, all []interface{}, depth int) interface{} {
+	merged := map[string]interface{}{}
+
+	if _, ok := n["properties"]; ok {
+		if obj, ok := g.objectExample(n, depth).(map[string]interface{}); ok {
+			merged = obj
+		}
+	}
+
+	for _, sub := range all {
+		v := g.example(sub, depth+1)
+
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			return g.example(combine(n, all), depth+1)
+		}
+
+		for k, value := range obj {
+			merged[k] = value
+		}
+	}
+
+	return merged
+}
+
+// combine merges the keywords of the allOf subschemas of n into one schema,
+// as allOf of non objects constrains a single value.
+func combine(n map[string]interface{}, all []interface{}) map[string]interface{} {
+	combined := map[string]interface{}{}
+
+	for k, v := range n {
+		if k != "allOf" {
+			combined[k] = v
+		}
+	}
+
+	for _, sub := range all {
+		if m, ok := sub.(map[string]interface{}); ok {
+			for k, v := range m {
+				combined[k] = v
+			}
+		}
+	}
+
+	return combined
+}
+
+func (g *exampleGenerator) arrayExample(n map[string]interface{}, depth int) interface{} {
+	items := []interface{}{}
+
+	if prefix, ok := n["prefixItems"].([]interface{}); ok {
+		for _, sub := range prefix {
+			items = append(items, g.example(sub, depth+1))
+		}
+	}
+
+	if tuple, ok := n["items"].([]interface{}); ok {
+		for _, sub := range tuple {
+			items = append(items, g.example(sub, depth+1))
+		}
+	}
+
+	count := 1
+	if min, ok := intKeyword(n, "minItems"); ok && min > count {
+		count = min
+	}
+
+	if max, ok := intKeyword(n, "maxItems"); ok && max < count {
+		count = max
+	}
+
+	sub, ok := n["items"].(map[string]interface{})
+	if !ok {
+		return items
+	}
+
+	if unique, _ := n["uniqueItems"].(bool); !unique {
+		for len(items) < count {
+			items = append(items, g.example(sub, depth+1))
+		}
+
+		return items
+	}
+
+	// Try further variants of the item until enough distinct ones are
+	// found, giving up when the schema allows too few values.
+	variant := g.variant
+	defer func() { g.variant = variant }()
+
+	for v := 0; len(items) < count && v < count+maxUniqueTries; v++ {
+		g.variant = v
+
+		item := g.example(sub, depth+1)
+		if !containsEqual(items, item) {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// maxUniqueTries bounds the variants tried beyond minItems when generating
+// the distinct items of an array.
+const maxUniqueTries = 100
+
+func containsEqual(items []interface{}, v interface{}) bool {
+	for _, item := range items {
+		if equal(item, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *exampleGenerator) objectExample(n map[string]interface{}, depth int) interface{} {
+	obj := map[string]interface{}{}
+
+	props, _ := n["properties"].(map[string]interface{})
+
+	required := map[string]bool{}
+	if req, ok := n["required"].([]interface{}); ok {
+		for _, r := range req {
+			if name, ok := r.(string); ok {
+				required[name] = true
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(props))
+	for key := range props {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if !required[key] && g.recurses(props[key]) {
+			continue
+		}
+
+		obj[key] = g.example(props[key], depth+1)
+	}
+
+	return obj
+}
+
+// recurses reports whether node refers to a schema already being expanded.
+func (g *exampleGenerator) recurses(node interface{}) bool {
+	n, ok := node.(map[string]interface{})
+	if !ok {
+		return false
+	}
+
+	if ref, ok := n["$ref"].(string); ok {
+		// The root is always being expanded.
+		return ref == "#" || g.refs[ref]
+	}
+
+	if items, ok := n["items"]; ok {
+		return g.recurses(items)
+	}
+
+	return false
+}
+
+func exampleType(n map[string]interface{}) string {
+	switch t := n["type"].(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, v := range t {
+			if name, ok := v.(string); ok && name != "null" {
+				return name
+			}
+		}
+	}
+
+	switch {
+	case n["properties"] != nil:
+		return "object"
+	case n["items"] != nil:
+		return "array"
+	}
+
+	return ""
+}
+
+// stringExample makes up a string of the format and length n requires. When
+// n has a pattern the string is made to look like what it requires, and is
+// generated from the pattern when that is not enough to match it.
+func (g *exampleGenerator) stringExample(n map[string]interface{}) string {
+	var s string
+
+	switch format, _ := n["format"].(string); format {
+	case "date-time":
+		return exampleTime.AddDate(0, 0, g.variant).Format(time.RFC3339)
+	case "date":
+		return exampleTime.AddDate(0, 0, g.variant).Format("2006-01-02")
+	case "email":
+		if g.variant > 0 {
+			return fmt.Sprintf("user%d@example.com", g.variant)
+		}
+
+		return "user@example.com"
+	case "uri":
+		if g.variant > 0 {
+			return fmt.Sprintf("https://example.com/%d", g.variant)
+		}
+
+		return "https://example.com"
+	case "uuid":
+		return fmt.Sprintf("123e4567-e89b-12d3-a456-%012d", 426614174000+g.variant)
+	default:
+		s = "string"
+	}
+
+	p, hasPattern := n["pattern"].(string)
+	if hasPattern {
+		s = literalPrefix(p) + s
+	}
+
+	min, hasMin := intKeyword(n, "minLength")
+	max, hasMax := intKeyword(n, "maxLength")
+
+	s = fitLength(s, g.variant, min, hasMin, max, hasMax)
+
+	if re := g.s.patterns[p]; hasPattern && re != nil && !re.MatchString(s) {
+		if generated, ok := patternExample(p, re, g.variant, min, hasMin, max, hasMax); ok {
+			return generated
+		}
+	}
+
+	return s
+}
+
+// fitLength appends the variant to s, unless it is the first, and pads the
+// result with x or cuts s to the length bounds, keeping the variant that
+// tells the examples apart.
+func fitLength(s string, variant, min int, hasMin bool, max int, hasMax bool) string {
+	suffix := ""
+	if variant > 0 {
+		suffix = strconv.Itoa(variant)
+	}
+
+	if hasMin && len(s)+len(suffix) < min {
+		s += strings.Repeat("x", min-len(s)-len(suffix))
+	}
+
+	if hasMax && len(s)+len(suffix) > max {
+		if len(suffix) > max {
+			return suffix[len(suffix)-max:]
+		}
+
+		s = s[:max-len(suffix)]
+	}
+
+	return s + suffix
+}
+
+// patternExample generates a string matching pattern within the length
+// bounds, repeating the repeatable parts of the pattern more and more until
+// the string is long enough.
+func patternExample(pattern string, re *regexp.Regexp, variant, min int, hasMin bool, max int, hasMax bool) (string, bool) {
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+
+	longest := 1
+	if hasMin && min > longest {
+		longest = min
+	}
+
+	for reps := 1; reps <= longest; reps++ {
+		var b strings.Builder
+		writeRegexpExample(&b, parsed, reps, variant)
+
+		s := b.String()
+		if hasMin && len(s) < min {
+			continue
+		}
+
+		if hasMax && len(s) > max {
+			return "", false
+		}
+
+		if re.MatchString(s) {
+			return s, true
+		}
+	}
+
+	return "", false
+}
+
+// writeRegexpExample writes a string matched by re, with reps repetitions
+// of its unbounded repeats and the character classes offset by variant.
+func writeRegexpExample(b *strings.Builder, re *syntax.Regexp, reps, variant int) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		if r, ok := classRune(re.Rune, variant); ok {
+			b.WriteRune(r)
+		}
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteRune(rune('a' + variant%26))
+	case syntax.OpCapture:
+		writeRegexpExample(b, re.Sub[0], reps, variant)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			writeRegexpExample(b, sub, reps, variant)
+		}
+	case syntax.OpAlternate:
+		writeRegexpExample(b, re.Sub[variant%len(re.Sub)], reps, variant)
+	case syntax.OpStar, syntax.OpPlus:
+		for i := 0; i < reps; i++ {
+			writeRegexpExample(b, re.Sub[0], reps, variant)
+		}
+	case syntax.OpQuest:
+		// Optional parts are left out.
+	case syntax.OpRepeat:
+		n := re.Min
+		if re.Max == -1 || re.Max > n {
+			n = repeatCount(re.Min, re.Max, reps)
+		}
+
+		for i := 0; i < n; i++ {
+			writeRegexpExample(b, re.Sub[0], reps, variant)
+		}
+	}
+}
+
+// repeatCount returns how many times to repeat what {min,max} applies to,
+// which is reps within the bounds. max is -1 when unbounded.
+func repeatCount(min, max, reps int) int {
+	n := reps
+	if n < min {
+		n = min
+	}
+
+	if max != -1 && n > max {
+		n = max
+	}
+
+	return n
+}
+
+// classRune picks a rune of a character class, given as ranges of runes,
+// preferring lower case letters and digits for readability. variant moves
+// the pick along the class.
+func classRune(ranges []rune, variant int) (rune, bool) {
+	if len(ranges) < 2 {
+		return 0, false
+	}
+
+	lo, hi := ranges[0], ranges[1]
+
+	for _, preferred := range [][2]rune{{'a', 'z'}, {'0', '9'}, {'A', 'Z'}} {
+		found := false
+
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if ranges[i] <= preferred[1] && ranges[i+1] >= preferred[0] {
+				lo, hi = ranges[i], ranges[i+1]
+				if lo < preferred[0] {
+					lo = preferred[0]
+				}
+
+				if hi > preferred[1] {
+					hi = preferred[1]
+				}
+
+				found = true
+
+				break
+			}
+		}
+
+		if found {
+			break
+		}
+	}
+
+	return lo + rune(variant)%(hi-lo+1), true
+}
+
+// literalPrefix returns the literal text a pattern anchored at the start
+// requires, such as "ord_" for "^ord_[a-z0-9]+$", so examples of prefixed
+// identifiers look right.
+func literalPrefix(pattern string) string {
+	if !strings.HasPrefix(pattern, "^") {
+		return ""
+	}
+
+	// Prog.Prefix stops at the ^ assertion, so parse what follows it.
+	re, err := syntax.Parse(strings.TrimPrefix(pattern, "^"), syntax.Perl)
+	if err != nil {
+		return ""
+	}
+
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return ""
+	}
+
+	prefix, _ := prog.Prefix()
+
+	return prefix
+}
+
+// numberExample makes up a number within the bounds of n. Variants after the
+// first are further multiples of multipleOf, or of 1, up or else down.
+func numberExample(n map[string]interface{}, integer bool, variant int) json.Number {
+	value := new(big.Rat)
+
+	if min, ok := ratKeyword(n, "minimum"); ok {
+		value = min
+	} else if min, ok := ratKeyword(n, "exclusiveMinimum"); ok {
+		value = min.Add(min, big.NewRat(1, 1))
+	} else if max, ok := ratKeyword(n, "maximum"); ok && max.Sign() < 0 {
+		value = max
+	} else if max, ok := ratKeyword(n, "exclusiveMaximum"); ok && max.Sign() <= 0 {
+		value = max.Sub(max, big.NewRat(1, 1))
+	}
+
+	// A step of 1 above an exclusive minimum may overshoot a close maximum,
+	// in which case the middle of the range is used.
+	if !integer && !belowMaximum(n, value) {
+		if min, ok := ratKeyword(n, "exclusiveMinimum"); ok {
+			max, ok := ratKeyword(n, "maximum")
+			if !ok {
+				max, _ = ratKeyword(n, "exclusiveMaximum")
+			}
+
+			if max != nil {
+				value = new(big.Rat).Quo(new(big.Rat).Add(min, max), big.NewRat(2, 1))
+			}
+		}
+	}
+
+	if m, ok := ratKeyword(n, "multipleOf"); ok && m.Sign() > 0 {
+		// Round up to the next multiple.
+		q := new(big.Rat).Quo(value, m)
+		ceil := new(big.Int).Quo(q.Num(), q.Denom())
+
+		if !q.IsInt() && q.Sign() > 0 {
+			ceil.Add(ceil, big.NewInt(1))
+		}
+
+		value = new(big.Rat).Mul(new(big.Rat).SetInt(ceil), m)
+	}
+
+	if variant > 0 {
+		step := big.NewRat(1, 1)
+		if m, ok := ratKeyword(n, "multipleOf"); ok && m.Sign() > 0 {
+			step = m
+		}
+
+		offset := new(big.Rat).Mul(step, big.NewRat(int64(variant), 1))
+		up := new(big.Rat).Add(value, offset)
+
+		if belowMaximum(n, up) {
+			value = up
+		} else {
+			value = new(big.Rat).Sub(value, offset)
+		}
+	}
+
+	if integer || value.IsInt() {
+		return json.Number(new(big.Int).Quo(value.Num(), value.Denom()).String())
+	}
+
+	return json.Number(strings.TrimRight(value.FloatString(6), "0"))
+}
+
+// belowMaximum reports whether v satisfies the maximum and exclusiveMaximum
+// of n.
+func belowMaximum(n map[string]interface{}, v *big.Rat) bool {
+	if max, ok := ratKeyword(n, "maximum"); ok && v.Cmp(max) > 0 {
+		return false
+	}
+
+	if max, ok := ratKeyword(n, "exclusiveMaximum"); ok && v.Cmp(max) >= 0 {
+		return false
+	}
+
+	return true
+}
+
+func ratKeyword(n map[string]interface{}, keyword string) (*big.Rat, bool) {
+	num, ok := n[keyword].(json.Number)
+	if !ok {
+		return nil, false
+	}
+
+	return rat(num)
+}
@@ -0,0 +1,159 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestExample(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{
+			name:   "examples win",
+			schema: `{"type": "string", "examples": ["ord_42"], "default": "ord_1"}`,
+			want:   `"ord_42"`,
+		},
+		{
+			name:   "const",
+			schema: `{"const": "paid"}`,
+			want:   `"paid"`,
+		},
+		{
+			name:   "enum",
+			schema: `{"enum": ["pending", "paid"]}`,
+			want:   `"pending"`,
+		},
+		{
+			name:   "date-time",
+			schema: `{"type": "string", "format": "date-time"}`,
+			want:   `"2024-01-01T12:00:00Z"`,
+		},
+		{
+			name: "required and optional properties",
+			schema: `{
+				"type": "object",
+				"required": ["id"],
+				"properties": {
+					"id": {"type": "string", "format": "uuid"},
+					"email": {"type": "string", "format": "email"},
+					"site": {"type": "string", "format": "uri"},
+					"day": {"type": "string", "format": "date"}
+				}
+			}`,
+		},
+		{
+			name: "bounds",
+			schema: `{
+				"type": "object",
+				"properties": {
+					"code": {"type": "string", "minLength": 12, "maxLength": 12},
+					"quantity": {"type": "integer", "minimum": 5, "maximum": 5},
+					"discount": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+					"step": {"type": "integer", "minimum": 7, "multipleOf": 5}
+				}
+			}`,
+		},
+		{
+			name:   "unique items",
+			schema: `{"type": "array", "minItems": 4, "uniqueItems": true, "items": {"type": "string"}}`,
+		},
+		{
+			name:   "unique integers within bounds",
+			schema: `{"type": "array", "minItems": 3, "uniqueItems": true, "items": {"type": "integer", "minimum": 1, "maximum": 3}}`,
+		},
+		{
+			name:   "unique objects",
+			schema: `{"type": "array", "minItems": 3, "uniqueItems": true, "items": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string"}}}}`,
+		},
+		{
+			name:   "pattern",
+			schema: `{"type": "string", "pattern": "^ord_[0-9]{4}$"}`,
+		},
+		{
+			name:   "pattern with length bounds",
+			schema: `{"type": "string", "pattern": "^[A-Z]{2}-[a-z]+$", "minLength": 6, "maxLength": 8}`,
+		},
+		{
+			name:   "unique items matching a pattern",
+			schema: `{"type": "array", "minItems": 3, "uniqueItems": true, "items": {"type": "string", "pattern": "^sku-[0-9]+$"}}`,
+		},
+		{
+			name: "recursion stops",
+			schema: `{
+				"type": "object",
+				"required": ["name"],
+				"properties": {
+					"name": {"type": "string"},
+					"children": {"type": "array", "items": {"$ref": "#"}}
+				}
+			}`,
+		},
+		{
+			name: "references and combinators",
+			schema: `{
+				"type": "object",
+				"required": ["item", "price"],
+				"properties": {
+					"item": {"$ref": "#/$defs/item"},
+					"price": {"allOf": [{"type": "number"}, {"minimum": 10}]},
+					"payment": {"oneOf": [{"type": "string"}, {"type": "integer"}]}
+				},
+				"$defs": {"item": {"type": "object", "required": ["sku"], "properties": {"sku": {"type": "string"}}}}
+			}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Compile([]byte(tt.schema))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			example, err := s.Example()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := s.Validate(example); err != nil {
+				t.Fatalf("example %s does not match the schema: %v", example, err.(*ValidationError).Errors)
+			}
+
+			if tt.want == "" {
+				return
+			}
+
+			var got, want interface{}
+			if err := json.Unmarshal(example, &got); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := json.Unmarshal([]byte(tt.want), &want); err != nil {
+				t.Fatal(err)
+			}
+
+			if got != want {
+				t.Errorf("got example %s, want %s", example, tt.want)
+			}
+		})
+	}
+}
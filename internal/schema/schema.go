@@ -0,0 +1,205 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package schema validates JSON documents against JSON Schemas. It covers the
+// keywords of draft 7 and later that describe event payloads: types, enums,
+// numeric and string bounds, formats, object and array constraints,
+// combinators and local $ref. Keywords it does not know are ignored.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// Error is a violation of the schema at the location given by a JSON
+// pointer, such as "/items/0/price".
+type Error struct {
+	Pointer string `json:"pointer"`
+	Message string `json:"message"`
+}
+
+func (e Error) String() string {
+	pointer := e.Pointer
+	if pointer == "" {
+		pointer = "(root)"
+	}
+
+	return pointer + ": " + e.Message
+}
+
+// ValidationError lists every violation found in a document.
+type ValidationError struct {
+	Errors []Error
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Errors) == 1 {
+		return "data does not match the schema: 1 error"
+	}
+
+	return fmt.Sprintf("data does not match the schema: %d errors", len(e.Errors))
+}
+
+// Compile parses a JSON Schema document.
+func Compile(data []byte) (*Schema, error) {
+	root, err := decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing schema: %w", err)
+	}
+
+	switch root.(type) {
+	case map[string]interface{}, bool:
+	default:
+		return nil, errors.New("parsing schema: must be an object or a boolean")
+	}
+
+	s := &Schema{root: root, patterns: map[string]*regexp.Regexp{}}
+	if err := s.compilePatterns(root); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Validate checks a JSON document against the schema, returning a
+// *ValidationError when it does not match.
+func (s *Schema) Validate(data []byte) error {
+	v, err := decode(data)
+	if err != nil {
+		return fmt.Errorf("parsing payload: %w", err)
+	}
+
+	if errs := s.validate(s.root, v, "", 0); len(errs) > 0 {
+		return &ValidationError{Errors: errs}
+	}
+
+	return nil
+}
+
+// decode parses JSON keeping numbers exact.
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	if dec.More() {
+		return nil, errors.New("unexpected data after the JSON value")
+	}
+
+	return v, nil
+}
+
+// compilePatterns compiles every pattern and patternProperties regular
+// expression up front, so invalid ones are reported by Compile.
+func (s *Schema) compilePatterns(node interface{}) error {
+	switch n := node.(type) {
+	case map[string]interface{}:
+		for key, value := range n {
+			if key == "pattern" {
+				if p, ok := value.(string); ok {
+					if err := s.compilePattern(p); err != nil {
+						return err
+					}
+				}
+			}
+
+			if key == "patternProperties" {
+				if props, ok := value.(map[string]interface{}); ok {
+					for p := range props {
+						if err := s.compilePattern(p); err != nil {
+							return err
+						}
+					}
+				}
+			}
+
+			if err := s.compilePatterns(value); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, value := range n {
+			if err := s.compilePatterns(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) compilePattern(p string) error {
+	if _, ok := s.patterns[p]; ok {
+		return nil
+	}
+
+	re, err := regexp.Compile(p)
+	if err != nil {
+		return fmt.Errorf("parsing schema: invalid pattern %q: %w", p, err)
+	}
+
+	s.patterns[p] = re
+
+	return nil
+}
+
+// resolve follows a local reference such as "#/$defs/address".
+func (s *Schema) resolve(ref string) (interface{}, error) {
+	if ref == "#" {
+		return s.root, nil
+	}
+
+	if !strings.HasPrefix(ref, "#/") {
+		return nil, fmt.Errorf("unsupported $ref %q, only references within the schema are resolved", ref)
+	}
+
+	node := s.root
+
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#/"), "/") {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+
+		obj, ok := node.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+
+		if node, ok = obj[token]; !ok {
+			return nil, fmt.Errorf("unresolvable $ref %q", ref)
+		}
+	}
+
+	return node, nil
+}
+
+// Pointer appends a reference token to a JSON pointer, escaping it.
+func Pointer(base, token string) string {
+	return base + "/" + strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
@@ -0,0 +1,139 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const orderSchema = `{
+	"type": "object",
+	"required": ["id", "total", "items"],
+	"additionalProperties": false,
+	"properties": {
+		"id": {"type": "string", "pattern": "^ord_[0-9]+$"},
+		"email": {"type": "string", "format": "email"},
+		"status": {"enum": ["pending", "paid"]},
+		"total": {"type": "number", "minimum": 0},
+		"items": {
+			"type": "array",
+			"minItems": 1,
+			"items": {"$ref": "#/$defs/item"}
+		}
+	},
+	"$defs": {
+		"item": {
+			"type": "object",
+			"required": ["sku"],
+			"properties": {
+				"sku": {"type": "string", "minLength": 3},
+				"quantity": {"type": "integer", "minimum": 1}
+			}
+		}
+	}
+}`
+
+func TestValidate(t *testing.T) {
+	s, err := Compile([]byte(orderSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		data string
+		want []string
+	}{
+		{
+			name: "valid",
+			data: `{"id":"ord_1","total":10.5,"status":"paid","items":[{"sku":"abc","quantity":2}]}`,
+		},
+		{
+			name: "missing required fields",
+			data: `{"id":"ord_1"}`,
+			want: []string{"/total", "/items"},
+		},
+		{
+			name: "nested violations",
+			data: `{"id":"ord_1","total":1,"items":[{"sku":"abc"},{"sku":"x","quantity":0}]}`,
+			want: []string{"/items/1/sku", "/items/1/quantity"},
+		},
+		{
+			name: "pattern, format and enum",
+			data: `{"id":"order-1","email":"nope","status":"lost","total":1,"items":[{"sku":"abc"}]}`,
+			want: []string{"/id", "/email", "/status"},
+		},
+		{
+			name: "wrong types and unknown field",
+			data: `{"id":1,"total":"1","items":[],"extra":true}`,
+			want: []string{"/id", "/total", "/items", "/extra"},
+		},
+		{
+			name: "large integers stay exact",
+			data: `{"id":"ord_1","total":1,"items":[{"sku":"abc","quantity":9007199254740993}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate([]byte(tt.data))
+			if len(tt.want) == 0 {
+				if err != nil {
+					t.Fatalf("got %v, want no error", err)
+				}
+
+				return
+			}
+
+			var verr *ValidationError
+			if !errors.As(err, &verr) {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+
+			got := map[string]int{}
+			for _, e := range verr.Errors {
+				pointer := strings.SplitN(e.String(), ":", 2)[0]
+				got[pointer]++
+			}
+
+			want := map[string]int{}
+			for _, p := range tt.want {
+				want[p]++
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("got errors %v, want at %v", verr.Errors, tt.want)
+			}
+
+			for p, n := range want {
+				if got[p] != n {
+					t.Errorf("got %d errors at %s, want %d (%v)", got[p], p, n, verr.Errors)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRejectsInvalidSchemas(t *testing.T) {
+	for _, data := range []string{`not json`, `[]`, `"string"`, `{"pattern":"("}`} {
+		if _, err := Compile([]byte(data)); err == nil {
+			t.Errorf("compiled %s, want an error", data)
+		}
+	}
+}
@@ -0,0 +1,497 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// maxDepth bounds $ref expansion so recursive schemas cannot loop forever.
+const maxDepth = 64
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+func (s *Schema) validate(node, v interface{}, ptr string, depth int) []Error {
+	if depth > maxDepth {
+		return []Error{{Pointer: ptr, Message: "schema nesting is too deep"}}
+	}
+
+	switch n := node.(type) {
+	case bool:
+		if !n {
+			return []Error{{Pointer: ptr, Message: "is not allowed"}}
+		}
+
+		return nil
+	case map[string]interface{}:
+		return s.validateObjectSchema(n, v, ptr, depth)
+	}
+
+	return nil
+}
+
+func (s *Schema) validateObjectSchema(n map[string]interface{}, v interface{}, ptr string, depth int) []Error {
+	if ref, ok := n["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			return []Error{{Pointer: ptr, Message: err.Error()}}
+		}
+
+		// Before draft 2019-09 keywords next to $ref are ignored, and most
+		// schemas written since only put $ref alone, so it is followed
+		// exclusively.
+		return s.validate(target, v, ptr, depth+1)
+	}
+
+	if t, ok := n["type"]; ok {
+		if msg := checkType(t, v); msg != "" {
+			return []Error{{Pointer: ptr, Message: msg}}
+		}
+	}
+
+	var errs []Error
+
+	if enum, ok := n["enum"].([]interface{}); ok {
+		found := false
+
+		for _, e := range enum {
+			if equal(e, v) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errs = append(errs, Error{Pointer: ptr, Message: "must be one of " + formatValues(enum)})
+		}
+	}
+
+	if c, ok := n["const"]; ok && !equal(c, v) {
+		errs = append(errs, Error{Pointer: ptr, Message: "must be " + formatValue(c)})
+	}
+
+	switch value := v.(type) {
+	case string:
+		errs = append(errs, s.validateString(n, value, ptr)...)
+	case json.Number:
+		errs = append(errs, validateNumber(n, value, ptr)...)
+	case map[string]interface{}:
+		errs = append(errs, s.validateObject(n, value, ptr, depth)...)
+	case []interface{}:
+		errs = append(errs, s.validateArray(n, value, ptr, depth)...)
+	}
+
+	errs = append(errs, s.validateCombinators(n, v, ptr, depth)...)
+
+	return errs
+}
+
+func (s *Schema) validateCombinators(n map[string]interface{}, v interface{}, ptr string, depth int) []Error {
+	var errs []Error
+
+	if all, ok := n["allOf"].([]interface{}); ok {
+		for _, sub := range all {
+			errs = append(errs, s.validate(sub, v, ptr, depth+1)...)
+		}
+	}
+
+	if anyOf, ok := n["anyOf"].([]interface{}); ok {
+		matched := false
+
+		for _, sub := range anyOf {
+			if len(s.validate(sub, v, ptr, depth+1)) == 0 {
+				matched = true
+				break
+			}
+		}
+
+		if !matched {
+			errs = append(errs, Error{Pointer: ptr, Message: "does not match any of the allowed schemas"})
+		}
+	}
+
+	if one, ok := n["oneOf"].([]interface{}); ok {
+		matches := 0
+
+		for _, sub := range one {
+			if len(s.validate(sub, v, ptr, depth+1)) == 0 {
+				matches++
+			}
+		}
+
+		if matches != 1 {
+			errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must match exactly one schema, matches %d", matches)})
+		}
+	}
+
+	if not, ok := n["not"]; ok && len(s.validate(not, v, ptr, depth+1)) == 0 {
+		errs = append(errs, Error{Pointer: ptr, Message: "matches a schema it must not match"})
+	}
+
+	if cond, ok := n["if"]; ok {
+		branch := "else"
+		if len(s.validate(cond, v, ptr, depth+1)) == 0 {
+			branch = "then"
+		}
+
+		if sub, ok := n[branch]; ok {
+			errs = append(errs, s.validate(sub, v, ptr, depth+1)...)
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) validateString(n map[string]interface{}, v, ptr string) []Error {
+	var errs []Error
+
+	length := utf8.RuneCountInString(v)
+
+	if min, ok := intKeyword(n, "minLength"); ok && length < min {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must be at least %d characters long", min)})
+	}
+
+	if max, ok := intKeyword(n, "maxLength"); ok && length > max {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must be at most %d characters long", max)})
+	}
+
+	if p, ok := n["pattern"].(string); ok && !s.patterns[p].MatchString(v) {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must match the pattern %q", p)})
+	}
+
+	if format, ok := n["format"].(string); ok && !validFormat(format, v) {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must be a valid %s", format)})
+	}
+
+	return errs
+}
+
+func validFormat(format, v string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse("2006-01-02", v)
+		return err == nil
+	case "email":
+		addr, err := mail.ParseAddress(v)
+		return err == nil && addr.Address == v
+	case "uri":
+		u, err := url.Parse(v)
+		return err == nil && u.Scheme != ""
+	case "uuid":
+		return uuidPattern.MatchString(v)
+	}
+
+	// Unknown formats are annotations only.
+	return true
+}
+
+func validateNumber(n map[string]interface{}, v json.Number, ptr string) []Error {
+	value, ok := rat(v)
+	if !ok {
+		return nil
+	}
+
+	var errs []Error
+
+	bound := func(keyword string, fails func(cmp int) bool, msg string) {
+		limit, ok := n[keyword].(json.Number)
+		if !ok {
+			return
+		}
+
+		if l, ok := rat(limit); ok && fails(value.Cmp(l)) {
+			errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("%s %s", msg, limit)})
+		}
+	}
+
+	bound("minimum", func(c int) bool { return c < 0 }, "must be greater than or equal to")
+	bound("maximum", func(c int) bool { return c > 0 }, "must be less than or equal to")
+	bound("exclusiveMinimum", func(c int) bool { return c <= 0 }, "must be greater than")
+	bound("exclusiveMaximum", func(c int) bool { return c >= 0 }, "must be less than")
+
+	if m, ok := n["multipleOf"].(json.Number); ok {
+		if d, ok := rat(m); ok && d.Sign() != 0 && !new(big.Rat).Quo(value, d).IsInt() {
+			errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must be a multiple of %s", m)})
+		}
+	}
+
+	return errs
+}
+
+func (s *Schema) validateObject(n map[string]interface{}, v map[string]interface{}, ptr string, depth int) []Error {
+	var errs []Error
+
+	if required, ok := n["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := v[name]; !present {
+					errs = append(errs, Error{Pointer: Pointer(ptr, name), Message: "is required"})
+				}
+			}
+		}
+	}
+
+	if min, ok := intKeyword(n, "minProperties"); ok && len(v) < min {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must have at least %d properties", min)})
+	}
+
+	if max, ok := intKeyword(n, "maxProperties"); ok && len(v) > max {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must have at most %d properties", max)})
+	}
+
+	properties, _ := n["properties"].(map[string]interface{})
+	patternProperties, _ := n["patternProperties"].(map[string]interface{})
+	additional, hasAdditional := n["additionalProperties"]
+
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		p := Pointer(ptr, name)
+		matched := false
+
+		if sub, ok := properties[name]; ok {
+			matched = true
+			errs = append(errs, s.validate(sub, v[name], p, depth+1)...)
+		}
+
+		for pattern, sub := range patternProperties {
+			if s.patterns[pattern].MatchString(name) {
+				matched = true
+				errs = append(errs, s.validate(sub, v[name], p, depth+1)...)
+			}
+		}
+
+		if matched || !hasAdditional {
+			continue
+		}
+
+		if allowed, ok := additional.(bool); ok && !allowed {
+			errs = append(errs, Error{Pointer: p, Message: "is not an allowed property"})
+			continue
+		}
+
+		errs = append(errs, s.validate(additional, v[name], p, depth+1)...)
+	}
+
+	return errs
+}
+
+func (s *Schema) validateArray(n map[string]interface{}, v []interface{}, ptr string, depth int) []Error {
+	var errs []Error
+
+	if min, ok := intKeyword(n, "minItems"); ok && len(v) < min {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must have at least %d items", min)})
+	}
+
+	if max, ok := intKeyword(n, "maxItems"); ok && len(v) > max {
+		errs = append(errs, Error{Pointer: ptr, Message: fmt.Sprintf("must have at most %d items", max)})
+	}
+
+	if unique, _ := n["uniqueItems"].(bool); unique {
+	outer:
+		for i := range v {
+			for j := 0; j < i; j++ {
+				if equal(v[i], v[j]) {
+					errs = append(errs, Error{Pointer: Pointer(ptr, strconv.Itoa(i)), Message: fmt.Sprintf("duplicates item %d", j)})
+					break outer
+				}
+			}
+		}
+	}
+
+	// Tuples are described by prefixItems since draft 2020-12 and by an
+	// array of schemas in items before.
+	prefix, _ := n["prefixItems"].([]interface{})
+	items := n["items"]
+
+	if tuple, ok := items.([]interface{}); ok {
+		prefix, items = tuple, n["additionalItems"]
+	}
+
+	for i, item := range v {
+		p := Pointer(ptr, strconv.Itoa(i))
+
+		switch {
+		case i < len(prefix):
+			errs = append(errs, s.validate(prefix[i], item, p, depth+1)...)
+		case items != nil:
+			errs = append(errs, s.validate(items, item, p, depth+1)...)
+		}
+	}
+
+	if contains, ok := n["contains"]; ok {
+		found := false
+
+		for _, item := range v {
+			if len(s.validate(contains, item, ptr, depth+1)) == 0 {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			errs = append(errs, Error{Pointer: ptr, Message: "must contain a matching item"})
+		}
+	}
+
+	return errs
+}
+
+// checkType returns why v is not of the type, or types, t.
+func checkType(t, v interface{}) string {
+	var types []string
+
+	switch t := t.(type) {
+	case string:
+		types = []string{t}
+	case []interface{}:
+		for _, name := range t {
+			if s, ok := name.(string); ok {
+				types = append(types, s)
+			}
+		}
+	}
+
+	actual := typeOf(v)
+
+	for _, want := range types {
+		if want == actual || (want == "number" && actual == "integer") {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("must be of type %s, got %s", strings.Join(types, " or "), actual)
+}
+
+func typeOf(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if r, ok := rat(v); ok && r.IsInt() {
+			return "integer"
+		}
+
+		return "number"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	}
+
+	return "unknown"
+}
+
+// equal compares two decoded JSON values, numbers by value.
+func equal(a, b interface{}) bool {
+	switch a := a.(type) {
+	case json.Number:
+		b, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+
+		ra, okA := rat(a)
+		rb, okB := rat(b)
+
+		return okA && okB && ra.Cmp(rb) == 0
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for k, va := range a {
+			vb, ok := b[k]
+			if !ok || !equal(va, vb) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+
+		for i := range a {
+			if !equal(a[i], b[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return a == b
+}
+
+func rat(n json.Number) (*big.Rat, bool) {
+	return new(big.Rat).SetString(n.String())
+}
+
+func intKeyword(n map[string]interface{}, keyword string) (int, bool) {
+	num, ok := n[keyword].(json.Number)
+	if !ok {
+		return 0, false
+	}
+
+	i, err := strconv.Atoi(num.String())
+
+	return i, err == nil
+}
+
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+
+	return string(data)
+}
+
+func formatValues(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		formatted[i] = formatValue(v)
+	}
+
+	return strings.Join(formatted, ", ")
+}
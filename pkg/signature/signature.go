@@ -0,0 +1,118 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+// Package signature computes and verifies the signatures Xigubo attaches to
+// webhook deliveries.
+//
+// Every delivery carries the time it was sent, in Unix seconds, in the
+// X-Xigubo-Timestamp header and one or more signatures in the
+// X-Xigubo-Signature header, formatted as "v1=<hex>" and separated by commas
+// while a secret is being rotated. A v1 signature is the HMAC-SHA256 of the
+// timestamp, a dot and the raw request body, keyed with the webhook secret.
+//
+// Services receiving deliveries should use a Verifier, which also rejects
+// deliveries whose timestamp is too old, usually through Middleware.
+package signature
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Xigubo-Signature"
+	HeaderTimestamp = "X-Xigubo-Timestamp"
+
+	// Scheme is the version prefix of the signatures this package produces.
+	Scheme = "v1"
+)
+
+var (
+	ErrMissingSignature = errors.New("signature: missing signature")
+	ErrMissingTimestamp = errors.New("signature: missing timestamp")
+	ErrInvalidTimestamp = errors.New("signature: invalid timestamp")
+	ErrMismatch         = errors.New("signature: no signature matches")
+)
+
+// Compute returns the hex encoded v1 signature of body sent at timestamp.
+func Compute(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks that header, the value of the signature header, holds a
+// valid signature of body sent at timestamp, the value of the timestamp
+// header. It does not check how old the timestamp is.
+func Verify(secret, header, timestamp string, body []byte) error {
+	if header == "" {
+		return ErrMissingSignature
+	}
+
+	ts, err := ParseTimestamp(timestamp)
+	if err != nil {
+		return err
+	}
+
+	expected := []byte(Compute(secret, ts, body))
+
+	for _, sig := range strings.Split(header, ",") {
+		scheme, value, ok := strings.Cut(strings.TrimSpace(sig), "=")
+		if !ok || scheme != Scheme {
+			continue
+		}
+
+		if hmac.Equal(expected, []byte(value)) {
+			return nil
+		}
+	}
+
+	return ErrMismatch
+}
+
+// ParseTimestamp parses the value of the timestamp header.
+func ParseTimestamp(value string) (int64, error) {
+	if value == "" {
+		return 0, ErrMissingTimestamp
+	}
+
+	ts, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0, ErrInvalidTimestamp
+	}
+
+	return ts, nil
+}
+
+// Sign returns the headers that authenticate body as a delivery sent at t.
+func Sign(secret string, t time.Time, body []byte) http.Header {
+	ts := t.Unix()
+
+	header := http.Header{}
+	header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	header.Set(HeaderSignature, Scheme+"="+Compute(secret, ts, body))
+
+	return header
+}
@@ -0,0 +1,205 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	testSecret = "whsec_test"
+	testBody   = `{"id":"evt_1","type":"order.created"}`
+)
+
+var testTime = time.Unix(1700000000, 0)
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	header := Sign(testSecret, testTime, []byte(testBody))
+
+	if got := header.Get(HeaderTimestamp); got != "1700000000" {
+		t.Fatalf("timestamp header = %q, want 1700000000", got)
+	}
+
+	if got := header.Get(HeaderSignature); !strings.HasPrefix(got, Scheme+"=") {
+		t.Fatalf("signature header = %q, want %s= prefix", got, Scheme)
+	}
+
+	err := Verify(testSecret, header.Get(HeaderSignature), header.Get(HeaderTimestamp), []byte(testBody))
+	if err != nil {
+		t.Fatalf("Verify() = %v, want nil", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	ts := strconv.FormatInt(testTime.Unix(), 10)
+	valid := Scheme + "=" + Compute(testSecret, testTime.Unix(), []byte(testBody))
+	rotated := Scheme + "=" + Compute("whsec_old", testTime.Unix(), []byte(testBody))
+
+	tests := []struct {
+		name      string
+		secret    string
+		header    string
+		timestamp string
+		body      string
+		want      error
+	}{
+		{name: "valid", header: valid, timestamp: ts, body: testBody},
+		{name: "tampered body", header: valid, timestamp: ts, body: testBody + " ", want: ErrMismatch},
+		{name: "wrong secret", secret: "whsec_other", header: valid, timestamp: ts, body: testBody, want: ErrMismatch},
+		{name: "tampered timestamp", header: valid, timestamp: "1700000001", body: testBody, want: ErrMismatch},
+		{name: "missing signature", timestamp: ts, body: testBody, want: ErrMissingSignature},
+		{name: "missing timestamp", header: valid, body: testBody, want: ErrMissingTimestamp},
+		{name: "invalid timestamp", header: valid, timestamp: "yesterday", body: testBody, want: ErrInvalidTimestamp},
+		{name: "no scheme", header: strings.TrimPrefix(valid, Scheme+"="), timestamp: ts, body: testBody, want: ErrMismatch},
+		{name: "unknown scheme", header: "v0=" + strings.TrimPrefix(valid, Scheme+"="), timestamp: ts, body: testBody, want: ErrMismatch},
+		{name: "garbage", header: "not a signature", timestamp: ts, body: testBody, want: ErrMismatch},
+		{name: "rotation new first", header: valid + "," + rotated, timestamp: ts, body: testBody},
+		{name: "rotation new last", header: rotated + ", " + valid, timestamp: ts, body: testBody},
+		{name: "rotation old secret", secret: "whsec_old", header: valid + "," + rotated, timestamp: ts, body: testBody},
+		{name: "rotation neither", secret: "whsec_other", header: valid + "," + rotated, timestamp: ts, body: testBody, want: ErrMismatch},
+		{name: "padded timestamp", header: valid, timestamp: " " + ts + " ", body: testBody},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := tt.secret
+			if secret == "" {
+				secret = testSecret
+			}
+
+			err := Verify(secret, tt.header, tt.timestamp, []byte(tt.body))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifierTolerance(t *testing.T) {
+	tests := []struct {
+		name      string
+		tolerance time.Duration
+		sent      time.Duration
+		want      error
+	}{
+		{name: "now", sent: 0},
+		{name: "recent past", sent: -4 * time.Minute},
+		{name: "near future", sent: 4 * time.Minute},
+		{name: "too old", sent: -6 * time.Minute, want: ErrTimestampOutOfTolerance},
+		{name: "too far ahead", sent: 6 * time.Minute, want: ErrTimestampOutOfTolerance},
+		{name: "custom tolerance", tolerance: time.Minute, sent: -2 * time.Minute, want: ErrTimestampOutOfTolerance},
+		{name: "custom tolerance future", tolerance: time.Minute, sent: 2 * time.Minute, want: ErrTimestampOutOfTolerance},
+		{name: "disabled", tolerance: -1, sent: -24 * time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &Verifier{
+				Secret:    testSecret,
+				Tolerance: tt.tolerance,
+				Now:       func() time.Time { return testTime },
+			}
+
+			header := Sign(testSecret, testTime.Add(tt.sent), []byte(testBody))
+
+			err := v.Verify(header.Get(HeaderSignature), header.Get(HeaderTimestamp), []byte(testBody))
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Verify() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifierChecksSignature(t *testing.T) {
+	v := &Verifier{Secret: "whsec_other", Now: func() time.Time { return testTime }}
+	header := Sign(testSecret, testTime, []byte(testBody))
+
+	err := v.Verify(header.Get(HeaderSignature), header.Get(HeaderTimestamp), []byte(testBody))
+	if !errors.Is(err, ErrMismatch) {
+		t.Fatalf("Verify() = %v, want %v", err, ErrMismatch)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	v := &Verifier{Secret: testSecret, Now: func() time.Time { return testTime }}
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   string
+		want   int
+	}{
+		{name: "valid", header: Sign(testSecret, testTime, []byte(testBody)), body: testBody, want: http.StatusOK},
+		{name: "tampered", header: Sign(testSecret, testTime, []byte(testBody)), body: testBody + "x", want: http.StatusUnauthorized},
+		{name: "wrong secret", header: Sign("whsec_other", testTime, []byte(testBody)), body: testBody, want: http.StatusUnauthorized},
+		{name: "expired", header: Sign(testSecret, testTime.Add(-time.Hour), []byte(testBody)), body: testBody, want: http.StatusUnauthorized},
+		{name: "unsigned", header: http.Header{}, body: testBody, want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			var got string
+
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				called = true
+
+				body, err := io.ReadAll(r.Body)
+				if err != nil {
+					t.Errorf("reading body: %v", err)
+				}
+
+				got = string(body)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/hook", strings.NewReader(tt.body))
+			for name, values := range tt.header {
+				req.Header[name] = values
+			}
+
+			rec := httptest.NewRecorder()
+			Middleware(v)(next).ServeHTTP(rec, req)
+
+			if rec.Code != tt.want {
+				t.Fatalf("status = %d, want %d", rec.Code, tt.want)
+			}
+
+			if tt.want != http.StatusOK {
+				if called {
+					t.Fatal("next handler called for a rejected request")
+				}
+
+				return
+			}
+
+			if !called {
+				t.Fatal("next handler not called")
+			}
+
+			if got != tt.body {
+				t.Fatalf("next handler read body %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
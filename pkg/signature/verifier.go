@@ -0,0 +1,117 @@
+// Copyright 2023 Edson Michaque
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// SPDX-License-Identifier: Apache-2.0
+
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultTolerance is how far a delivery timestamp may be from the current
+// time before the delivery is rejected as a possible replay.
+const DefaultTolerance = 5 * time.Minute
+
+const maxBodySize = 5 << 20
+
+var ErrTimestampOutOfTolerance = errors.New("signature: timestamp outside the tolerance window")
+
+// Verifier checks signed deliveries.
+type Verifier struct {
+	// Secret is the webhook secret the deliveries are signed with.
+	Secret string
+	// Tolerance bounds the age of a delivery. DefaultTolerance is used when
+	// zero and the check is disabled when negative.
+	Tolerance time.Duration
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// Verify checks the signature and timestamp header values against body.
+func (v *Verifier) Verify(header, timestamp string, body []byte) error {
+	ts, err := ParseTimestamp(timestamp)
+	if err != nil {
+		return err
+	}
+
+	if err := v.checkTimestamp(ts); err != nil {
+		return err
+	}
+
+	return Verify(v.Secret, header, timestamp, body)
+}
+
+// VerifyRequest verifies a delivery request and returns its body. The body
+// of r is replaced so it can be read again by the caller.
+func (v *Verifier) VerifyRequest(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	r.Body.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	if err := v.Verify(r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body); err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (v *Verifier) checkTimestamp(ts int64) error {
+	tolerance := v.Tolerance
+	if tolerance == 0 {
+		tolerance = DefaultTolerance
+	}
+
+	if tolerance < 0 {
+		return nil
+	}
+
+	now := time.Now
+	if v.Now != nil {
+		now = v.Now
+	}
+
+	age := now().Sub(time.Unix(ts, 0))
+	if age > tolerance || age < -tolerance {
+		return ErrTimestampOutOfTolerance
+	}
+
+	return nil
+}
+
+// Middleware rejects requests that are not validly signed deliveries with
+// 401 Unauthorized and passes the others to next, with their body intact.
+//
+//	verifier := &signature.Verifier{Secret: os.Getenv("XIGUBO_WEBHOOK_SECRET")}
+//	http.Handle("/hook", signature.Middleware(verifier)(handler))
+func Middleware(v *Verifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if _, err := v.VerifyRequest(r); err != nil {
+				http.Error(w, err.Error(), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}